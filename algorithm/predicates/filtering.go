@@ -14,50 +14,187 @@
 package predicates
 
 import (
+	"context"
 	"fmt"
 
+	"gpu-scheduler/config"
+	"gpu-scheduler/logging"
+	"gpu-scheduler/parallelize"
 	resource "gpu-scheduler/resourceinfo"
+	framework "gpu-scheduler/vlalpha1"
 
 	corev1 "k8s.io/api/core/v1"
 )
 
-func Filtering(newPod *corev1.Pod) ([]*resource.NodeInfo, error) {
-	fmt.Println("1. Filtering statge")
+const (
+	// minFeasibleNodesToFind is the number of feasible nodes always looked
+	// for, so clusters smaller than this are filtered entirely.
+	minFeasibleNodesToFind = 100
+	// minFeasibleNodesPercentageToFind is the lowest percentage of nodes
+	// looked for when the percentage is picked from the cluster size.
+	minFeasibleNodesPercentageToFind = 5
+)
+
+// nextStartNodeIndex is the node the next filtering starts from, so that
+// every node gets its turn when filtering stops early.
+var nextStartNodeIndex int
+
+func Filtering(ctx context.Context, fwk *framework.Framework, state *framework.CycleState, metrics resource.MetricsProvider, newPod *corev1.Pod) ([]*resource.NodeInfo, error) {
+	logger := logging.FromContext(ctx)
+
+	if metrics == nil {
+		return nil, fmt.Errorf("metrics provider is not initialized")
+	}
 
 	//새 파드 필터링 전 노드 정보 업데이트
 	var NodeInfoList []*resource.NodeInfo
-	var NodeMetricList []*resource.NodeMetric
-	NodeInfoList, NodeMetricList, err := resource.NodeUpdate(NodeInfoList, NodeMetricList)
+	NodeInfoList, err := resource.NodeUpdate(NodeInfoList)
 	if err != nil {
-		fmt.Println("Filtering>nodeUpdate error: ", err)
 		return nil, err
 	}
+	state.SetSnapshot(NodeInfoList)
 
-	//debugging
-	fmt.Print("-Before Filtering Nodes")
-	for _, nodeinfo := range NodeInfoList {
-		if !nodeinfo.IsFiltered {
-			fmt.Print(" | ", nodeinfo.NodeName)
+	fitError := &FitError{
+		Pod:          newPod,
+		NumAllNodes:  len(NodeInfoList),
+		NodeToStatus: make(map[string]*framework.Status),
+	}
+
+	status := fwk.RunPreFilterPlugins(ctx, state, newPod)
+	switch status.Code() {
+	case framework.Success:
+	case framework.Unschedulable:
+		//모든 노드에 같은 이유 적용
+		for _, nodeinfo := range NodeInfoList {
+			fitError.NodeToStatus[nodeinfo.NodeName] = status
+			nodeinfo.FilterNode()
 		}
+		return nil, fitError
+	default:
+		return nil, status.AsError()
 	}
-	fmt.Println()
 
-	//1. PodFitsResourcesAndGPU
-	err = PodFitsResourcesAndGPU(NodeInfoList, newPod)
-	if err != nil {
-		fmt.Println("Filtering>PodFitsResourcesAndGPU error: ", err)
+	numAllNodes := len(NodeInfoList)
+	numNodesToFind := numFeasibleNodesToFind(numAllNodes)
+	startIndex := 0
+	if numAllNodes > 0 {
+		startIndex = nextStartNodeIndex % numAllNodes
+	}
+
+	//노드별 결과는 노드 순서대로 저장해 고루틴 실행 순서와 무관하게 같은 결과
+	statuses := make([]*framework.Status, numAllNodes)
+	evaluated := make([]bool, numAllNodes)
+
+	filterCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errCh := parallelize.NewErrorChannel()
+	checkNode := func(i int) {
+		index := (startIndex + i) % numAllNodes
+		nodeinfo := NodeInfoList[index]
+		status := nodeStatus(nodeinfo)
+		if status.IsSuccess() {
+			//필터링하는 노드의 메트릭만 조회, GPU 필터가 최신 GPU 목록을 사용
+			resource.UpdateNodeMetric(filterCtx, metrics, nodeinfo)
+			status = fwk.RunFilterPlugins(filterCtx, state, newPod, nodeinfo)
+		}
+		switch status.Code() {
+		case framework.Success:
+			//충분한 노드를 찾으면 나머지 노드는 필터링하지 않음
+			if state.AddFeasibleNode() >= numNodesToFind {
+				cancel()
+			}
+		case framework.Unschedulable:
+		default:
+			errCh.SendErrorWithCancel(status.AsError(), cancel)
+			return
+		}
+		statuses[index] = status
+		evaluated[index] = true
+	}
+	parallelize.Until(filterCtx, config.Parallelism, numAllNodes, checkNode)
+	if err := errCh.ReceiveError(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	//debugging
-	fmt.Print("-After Filtering Nodes")
-	for _, nodeinfo := range NodeInfoList {
-		if !nodeinfo.IsFiltered {
-			fmt.Print(" | ", nodeinfo.NodeName)
+	//시작 노드부터 순서대로 필요한 수만큼 선택, 결과는 노드 이름 순서 유지
+	selected := make([]bool, numAllNodes)
+	numFeasible, processed := 0, 0
+	for i := 0; i < numAllNodes && numFeasible < numNodesToFind; i++ {
+		index := (startIndex + i) % numAllNodes
+		if !evaluated[index] {
+			continue
 		}
+		processed = i + 1
+		if status := statuses[index]; !status.IsSuccess() {
+			fitError.NodeToStatus[NodeInfoList[index].NodeName] = status
+			NodeInfoList[index].FilterNode()
+			continue
+		}
+		selected[index] = true
+		numFeasible++
+	}
+	if numAllNodes > 0 {
+		nextStartNodeIndex = (startIndex + processed) % numAllNodes
 	}
-	fmt.Println()
 
-	return NodeInfoList, nil
+	feasibleNodes := make([]*resource.NodeInfo, 0, numFeasible)
+	for index, nodeinfo := range NodeInfoList {
+		if selected[index] {
+			feasibleNodes = append(feasibleNodes, nodeinfo)
+		}
+	}
+
+	//verbose 모드에서만 노드별 필터링 결과 출력
+	if v := logger.V(4); v.Enabled() {
+		for index, nodeinfo := range NodeInfoList {
+			if status, ok := fitError.NodeToStatus[nodeinfo.NodeName]; ok {
+				v.Info("Filtered node", "node", nodeinfo.NodeName, "reason", status.Message())
+			} else if selected[index] {
+				v.Info("Node passed filtering", "node", nodeinfo.NodeName)
+			}
+		}
+	}
+	logger.V(2).Info("Filtered nodes", "feasibleNodes", numFeasible, "evaluatedNodes", processed, "allNodes", numAllNodes)
 
+	//no node to allocate
+	if state.FeasibleNodes() == 0 {
+		return nil, fitError
+	}
+
+	return feasibleNodes, nil
+
+}
+
+// numFeasibleNodesToFind returns how many feasible nodes are looked for
+// before filtering stops, from config.PercentageOfNodesToScore.
+func numFeasibleNodesToFind(numAllNodes int) int {
+	if numAllNodes < minFeasibleNodesToFind || config.PercentageOfNodesToScore >= 100 {
+		return numAllNodes
+	}
+	percentage := config.PercentageOfNodesToScore
+	if percentage == 0 {
+		//kube-scheduler와 같이 노드가 많을수록 낮은 비율 사용
+		percentage = 50 - numAllNodes/125
+		if percentage < minFeasibleNodesPercentageToFind {
+			percentage = minFeasibleNodesPercentageToFind
+		}
+	}
+	numNodes := numAllNodes * percentage / 100
+	if numNodes < minFeasibleNodesToFind {
+		return minFeasibleNodesToFind
+	}
+	return numNodes
+}
+
+// nodeStatus filters the nodes no pod is scheduled to, whatever plugins
+// the profile enables. Control plane nodes are left to TaintToleration, so
+// that pods tolerating their taint can run there.
+func nodeStatus(nodeinfo *resource.NodeInfo) *framework.Status {
+	if nodeinfo.Node.Spec.Unschedulable {
+		return framework.NewStatus(framework.Unschedulable, ErrReasonUnschedulable)
+	}
+	return nil
 }
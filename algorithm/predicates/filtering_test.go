@@ -0,0 +1,35 @@
+package predicates
+
+import (
+	"testing"
+
+	"gpu-scheduler/config"
+)
+
+func TestNumFeasibleNodesToFind(t *testing.T) {
+	defer func(percentage int) { config.PercentageOfNodesToScore = percentage }(config.PercentageOfNodesToScore)
+
+	tests := []struct {
+		name        string
+		percentage  int
+		numAllNodes int
+		want        int
+	}{
+		{name: "small cluster is filtered entirely", percentage: 10, numAllNodes: 50, want: 50},
+		{name: "100 percent", percentage: 100, numAllNodes: 1000, want: 1000},
+		{name: "configured percentage", percentage: 30, numAllNodes: 1000, want: 300},
+		{name: "configured percentage below the minimum", percentage: 10, numAllNodes: 500, want: minFeasibleNodesToFind},
+		{name: "adaptive on 100 nodes", numAllNodes: 100, want: minFeasibleNodesToFind},
+		{name: "adaptive on 1000 nodes", numAllNodes: 1000, want: 420},
+		{name: "adaptive on 5000 nodes", numAllNodes: 5000, want: 500},
+		{name: "adaptive percentage is at least 5", numAllNodes: 6000, want: 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.PercentageOfNodesToScore = tt.percentage
+			if got := numFeasibleNodesToFind(tt.numAllNodes); got != tt.want {
+				t.Errorf("numFeasibleNodesToFind(%d) = %d, want %d", tt.numAllNodes, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package predicates
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	framework "gpu-scheduler/vlalpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ErrReasonUnschedulable is the reason of the nodes filtered before the
+// filter plugins run.
+const ErrReasonUnschedulable = "node(s) were unschedulable"
+
+// FitError is returned when no node passed filtering. It keeps the reason
+// every node was filtered for.
+type FitError struct {
+	Pod         *corev1.Pod
+	NumAllNodes int
+	// NodeToStatus는 노드 이름별 필터링 실패 이유
+	NodeToStatus map[string]*framework.Status
+}
+
+// Error returns e.g. "0/12 nodes are available: 7 Insufficient keti.com/mpsgpu, 2 node(s) had taint {node-role.kubernetes.io/master: }, that the pod didn't tolerate."
+func (f *FitError) Error() string {
+	reasonCount := make(map[string]int)
+	for _, status := range f.NodeToStatus {
+		for _, reason := range status.Reasons() {
+			reasonCount[reason]++
+		}
+	}
+
+	reasons := make([]string, 0, len(reasonCount))
+	for reason := range reasonCount {
+		reasons = append(reasons, reason)
+	}
+	//많이 발생한 이유 먼저
+	sort.Slice(reasons, func(i, j int) bool {
+		if reasonCount[reasons[i]] != reasonCount[reasons[j]] {
+			return reasonCount[reasons[i]] > reasonCount[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	for i, reason := range reasons {
+		reasons[i] = fmt.Sprintf("%d %s", reasonCount[reason], reason)
+	}
+
+	message := fmt.Sprintf("0/%d nodes are available", f.NumAllNodes)
+	if len(reasons) == 0 {
+		return message + "."
+	}
+	return message + ": " + strings.Join(reasons, ", ") + "."
+}
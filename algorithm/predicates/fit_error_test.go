@@ -0,0 +1,63 @@
+package predicates
+
+import (
+	"testing"
+
+	framework "gpu-scheduler/vlalpha1"
+)
+
+func TestFitErrorError(t *testing.T) {
+	const insufficientMPSGPU = "Insufficient keti.com/mpsgpu"
+	unschedulable := func(reasons ...string) *framework.Status {
+		return framework.NewStatus(framework.Unschedulable, reasons...)
+	}
+	tests := []struct {
+		name         string
+		numAllNodes  int
+		nodeToStatus map[string]*framework.Status
+		want         string
+	}{
+		{
+			name:        "no nodes",
+			numAllNodes: 0,
+			want:        "0/0 nodes are available.",
+		},
+		{
+			name:        "same reason is counted per node",
+			numAllNodes: 2,
+			nodeToStatus: map[string]*framework.Status{
+				"node-1": unschedulable(insufficientMPSGPU),
+				"node-2": unschedulable(insufficientMPSGPU),
+			},
+			want: "0/2 nodes are available: 2 Insufficient keti.com/mpsgpu.",
+		},
+		{
+			name:        "most frequent reason first, then by name",
+			numAllNodes: 4,
+			nodeToStatus: map[string]*framework.Status{
+				"node-1": unschedulable(ErrReasonUnschedulable),
+				"node-2": unschedulable(insufficientMPSGPU),
+				"node-3": unschedulable(insufficientMPSGPU),
+				"node-4": unschedulable(ErrReasonNodeAffinity),
+			},
+			want: "0/4 nodes are available: 2 Insufficient keti.com/mpsgpu, 1 node(s) didn't match Pod's node affinity/selector, 1 node(s) were unschedulable.",
+		},
+		{
+			name:        "every reason of a node is counted",
+			numAllNodes: 2,
+			nodeToStatus: map[string]*framework.Status{
+				"node-1": unschedulable(insufficientMPSGPU, "Insufficient cpu"),
+				"node-2": unschedulable("Insufficient cpu"),
+			},
+			want: "0/2 nodes are available: 2 Insufficient cpu, 1 Insufficient keti.com/mpsgpu.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &FitError{NumAllNodes: tt.numAllNodes, NodeToStatus: tt.nodeToStatus}
+			if got := err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package predicates
+
+import (
+	resource "gpu-scheduler/resourceinfo"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// insufficientGPU returns the GPU resources the node cannot provide for the
+// summed request of all containers of the pod.
+func insufficientGPU(nodeinfo *resource.NodeInfo, mpsReq int64, gpuReq int64) []string {
+	insufficient := make([]string, 0)
+
+	//MPS GPU: 노드 할당량과 실제로 비어있는 MPS 슬롯이 있는 GPU 개수 모두 확인
+	if mpsReq > 0 {
+		freeDevices, _ := resource.Allocator.FreeGPUs(nodeinfo.NodeName)
+		if !fitsExtendedResource(nodeinfo, resource.MPSGPUResource, mpsReq) || int64(freeDevices) < mpsReq {
+			insufficient = append(insufficient, "Insufficient "+string(resource.MPSGPUResource))
+		}
+	}
+
+	//whole GPU: 노드 할당량에서 기존 파드 요청량을 뺀 만큼만 사용 가능
+	if gpuReq > 0 {
+		if !fitsExtendedResource(nodeinfo, resource.NvidiaGPUResource, gpuReq) {
+			insufficient = append(insufficient, "Insufficient "+string(resource.NvidiaGPUResource))
+		}
+	}
+
+	return insufficient
+}
+
+func fitsExtendedResource(nodeinfo *resource.NodeInfo, name corev1.ResourceName, request int64) bool {
+	allocatable, ok := nodeinfo.Node.Status.Allocatable[name]
+	if !ok {
+		return false
+	}
+
+	var requested int64
+	for _, pod := range nodeinfo.Pods {
+		requested += resource.ExtendedResourceRequest(pod, name)
+	}
+	return allocatable.Value()-requested >= request
+}
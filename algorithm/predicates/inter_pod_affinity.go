@@ -0,0 +1,237 @@
+package predicates
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"gpu-scheduler/config"
+	"gpu-scheduler/parallelize"
+	resource "gpu-scheduler/resourceinfo"
+	framework "gpu-scheduler/vlalpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	InterPodAffinityName = "InterPodAffinity"
+
+	// ErrReasonExistingAntiAffinityRulesNotMatch is the reason of the nodes
+	// whose pods have a required anti-affinity against the pod.
+	ErrReasonExistingAntiAffinityRulesNotMatch = "node(s) didn't satisfy existing pods anti-affinity rules"
+	// ErrReasonAffinityRulesNotMatch is the reason of the nodes not matching
+	// the required pod affinity of the pod.
+	ErrReasonAffinityRulesNotMatch = "node(s) didn't match pod affinity rules"
+	// ErrReasonAntiAffinityRulesNotMatch is the reason of the nodes not
+	// matching the required pod anti-affinity of the pod.
+	ErrReasonAntiAffinityRulesNotMatch = "node(s) didn't match pod anti-affinity rules"
+
+	interPodAffinityStateKey framework.StateKey = "PreFilter" + InterPodAffinityName
+)
+
+// topologyPair is a topology domain: a node label key and its value.
+type topologyPair struct {
+	key   string
+	value string
+}
+
+// topologyToMatchedTermCount counts the matching pods in each topology domain.
+type topologyToMatchedTermCount map[topologyPair]int64
+
+func (m topologyToMatchedTermCount) append(other topologyToMatchedTermCount) {
+	for pair, count := range other {
+		m[pair] += count
+	}
+}
+
+// updateWithAffinityTerms counts the existing pod in the domains of the node
+// when it matches every term of the incoming pod.
+func (m topologyToMatchedTermCount) updateWithAffinityTerms(terms []resource.AffinityTerm, pod *corev1.Pod, node *corev1.Node) {
+	if len(terms) == 0 || !podMatchesAllAffinityTerms(terms, pod) {
+		return
+	}
+	for _, t := range terms {
+		if value, ok := node.Labels[t.TopologyKey]; ok {
+			m[topologyPair{key: t.TopologyKey, value: value}]++
+		}
+	}
+}
+
+// updateWithAntiAffinityTerms counts the pod in the domains of the node for
+// every term it matches.
+func (m topologyToMatchedTermCount) updateWithAntiAffinityTerms(terms []resource.AffinityTerm, pod *corev1.Pod, node *corev1.Node) {
+	for _, t := range terms {
+		if !t.Matches(pod) {
+			continue
+		}
+		if value, ok := node.Labels[t.TopologyKey]; ok {
+			m[topologyPair{key: t.TopologyKey, value: value}]++
+		}
+	}
+}
+
+func podMatchesAllAffinityTerms(terms []resource.AffinityTerm, pod *corev1.Pod) bool {
+	if len(terms) == 0 {
+		return false
+	}
+	for _, t := range terms {
+		if !t.Matches(pod) {
+			return false
+		}
+	}
+	return true
+}
+
+// interPodAffinityState is what Filter needs for every node, computed once
+// per attempt from the pods of all nodes, assumed pods included.
+type interPodAffinityState struct {
+	// existingAntiAffinityCounts counts the existing pods whose required
+	// anti-affinity matches the incoming pod.
+	existingAntiAffinityCounts topologyToMatchedTermCount
+	// affinityCounts counts the existing pods matching the required
+	// affinity of the incoming pod.
+	affinityCounts topologyToMatchedTermCount
+	// antiAffinityCounts counts the existing pods matching the required
+	// anti-affinity of the incoming pod.
+	antiAffinityCounts topologyToMatchedTermCount
+
+	affinityTerms     []resource.AffinityTerm
+	antiAffinityTerms []resource.AffinityTerm
+	pod               *corev1.Pod
+}
+
+// InterPodAffinity filters the nodes by the required pod affinity and
+// anti-affinity of the pod and of the pods already placed. The topology key
+// of a term can be any node label, e.g. kubernetes.io/hostname,
+// topology.kubernetes.io/zone or a custom gpu-rack label.
+type InterPodAffinity struct{}
+
+var _ framework.PreFilterPlugin = &InterPodAffinity{}
+var _ framework.FilterPlugin = &InterPodAffinity{}
+
+func (pl *InterPodAffinity) Name() string {
+	return InterPodAffinityName
+}
+
+func (pl *InterPodAffinity) PreFilter(ctx context.Context, state *framework.CycleState, newPod *corev1.Pod) *framework.Status {
+	affinityTerms, err := resource.GetAffinityTerms(newPod, resource.GetPodAffinityTerms(newPod))
+	if err != nil {
+		return framework.AsStatus(fmt.Errorf("failed to parse pod affinity of pod (%s),reason: %v", newPod.Name, err))
+	}
+	antiAffinityTerms, err := resource.GetAffinityTerms(newPod, resource.GetPodAntiAffinityTerms(newPod))
+	if err != nil {
+		return framework.AsStatus(fmt.Errorf("failed to parse pod anti-affinity of pod (%s),reason: %v", newPod.Name, err))
+	}
+
+	s := &interPodAffinityState{
+		existingAntiAffinityCounts: make(topologyToMatchedTermCount),
+		affinityCounts:             make(topologyToMatchedTermCount),
+		antiAffinityCounts:         make(topologyToMatchedTermCount),
+		affinityTerms:              affinityTerms,
+		antiAffinityTerms:          antiAffinityTerms,
+		pod:                        newPod,
+	}
+
+	//노드별로 병렬 집계한 뒤 합침
+	var mu sync.Mutex
+	nodes := state.Snapshot()
+	parallelize.Until(ctx, config.Parallelism, len(nodes), func(i int) {
+		node := &nodes[i].Node
+		existingAntiAffinity := make(topologyToMatchedTermCount)
+		affinity := make(topologyToMatchedTermCount)
+		antiAffinity := make(topologyToMatchedTermCount)
+		for _, existingPod := range nodes[i].Pods {
+			affinity.updateWithAffinityTerms(affinityTerms, existingPod, node)
+			antiAffinity.updateWithAntiAffinityTerms(antiAffinityTerms, existingPod, node)
+
+			//기존 파드의 anti-affinity가 새 파드를 밀어내는지 확인
+			existingTerms, err := resource.GetAffinityTerms(existingPod, resource.GetPodAntiAffinityTerms(existingPod))
+			if err != nil {
+				//API 서버가 검증한 파드이므로 잘못된 term은 무시
+				continue
+			}
+			existingAntiAffinity.updateWithAntiAffinityTerms(existingTerms, newPod, node)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		s.existingAntiAffinityCounts.append(existingAntiAffinity)
+		s.affinityCounts.append(affinity)
+		s.antiAffinityCounts.append(antiAffinity)
+	})
+	if err := ctx.Err(); err != nil {
+		return framework.AsStatus(err)
+	}
+
+	state.Write(interPodAffinityStateKey, s)
+	return nil
+}
+
+func (pl *InterPodAffinity) Filter(ctx context.Context, state *framework.CycleState, newPod *corev1.Pod, nodeinfo *resource.NodeInfo) *framework.Status {
+	data, err := state.Read(interPodAffinityStateKey)
+	if err != nil {
+		return framework.AsStatus(fmt.Errorf("failed to read %q from cycle state,reason: %v", interPodAffinityStateKey, err))
+	}
+	s, ok := data.(*interPodAffinityState)
+	if !ok {
+		return framework.AsStatus(fmt.Errorf("%+v convert to predicates.interPodAffinityState error", data))
+	}
+
+	node := &nodeinfo.Node
+	if !satisfyExistingPodsAntiAffinity(s, node) {
+		return framework.NewStatus(framework.Unschedulable, ErrReasonExistingAntiAffinityRulesNotMatch)
+	}
+	if !satisfyPodAntiAffinity(s, node) {
+		return framework.NewStatus(framework.Unschedulable, ErrReasonAntiAffinityRulesNotMatch)
+	}
+	if !satisfyPodAffinity(s, node) {
+		return framework.NewStatus(framework.Unschedulable, ErrReasonAffinityRulesNotMatch)
+	}
+	return nil
+}
+
+// satisfyExistingPodsAntiAffinity reports whether no pod in any domain of
+// the node has a required anti-affinity against the incoming pod.
+func satisfyExistingPodsAntiAffinity(s *interPodAffinityState, node *corev1.Node) bool {
+	if len(s.existingAntiAffinityCounts) == 0 {
+		return true
+	}
+	for key, value := range node.Labels {
+		if s.existingAntiAffinityCounts[topologyPair{key: key, value: value}] > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// satisfyPodAntiAffinity reports whether no pod matching a required
+// anti-affinity term of the incoming pod is in the domain of the node.
+func satisfyPodAntiAffinity(s *interPodAffinityState, node *corev1.Node) bool {
+	for _, t := range s.antiAffinityTerms {
+		if value, ok := node.Labels[t.TopologyKey]; ok && s.antiAffinityCounts[topologyPair{key: t.TopologyKey, value: value}] > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// satisfyPodAffinity reports whether every required affinity term of the
+// incoming pod has a matching pod in the domain of the node.
+func satisfyPodAffinity(s *interPodAffinityState, node *corev1.Node) bool {
+	podsExist := true
+	for _, t := range s.affinityTerms {
+		value, ok := node.Labels[t.TopologyKey]
+		if !ok {
+			//토폴로지 레이블이 없는 노드는 어떤 도메인에도 속하지 않음
+			return false
+		}
+		if s.affinityCounts[topologyPair{key: t.TopologyKey, value: value}] <= 0 {
+			podsExist = false
+		}
+	}
+	if podsExist {
+		return true
+	}
+	//그룹의 첫 파드: 어디에도 맞는 파드가 없고 자기 자신이 term에 맞으면 허용
+	return len(s.affinityCounts) == 0 && podMatchesAllAffinityTerms(s.affinityTerms, s.pod)
+}
@@ -0,0 +1,111 @@
+package predicates
+
+import (
+	"context"
+	"testing"
+
+	resource "gpu-scheduler/resourceinfo"
+	st "gpu-scheduler/testing"
+	framework "gpu-scheduler/vlalpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const rackKey = "gpu-rack"
+
+func rackNode(name, rack string, pods ...*corev1.Pod) *resource.NodeInfo {
+	node := st.MakeNode().Name(name)
+	if rack != "" {
+		node.Label(rackKey, rack)
+	}
+	return resource.NewNodeInfo(node.Obj(), pods...)
+}
+
+func rolePod(name, role string) *st.PodWrapper {
+	return st.MakePod().Namespace("ml").Name(name).Label("role", role)
+}
+
+func TestInterPodAffinityFilter(t *testing.T) {
+	ps := map[string]string{"role": "ps"}
+	trainer := map[string]string{"role": "trainer"}
+
+	tests := []struct {
+		name  string
+		pod   *corev1.Pod
+		nodes []*resource.NodeInfo
+		want  map[string]string //node -> reason, "" if the node fits
+	}{
+		{
+			name: "anti-affinity keeps parameter servers off the same host",
+			pod:  rolePod("ps-1", "ps").PodAntiAffinity(corev1.LabelHostname, ps).Obj(),
+			nodes: []*resource.NodeInfo{
+				rackNode("n1", "r1", rolePod("ps-0", "ps").PodAntiAffinity(corev1.LabelHostname, ps).Obj()),
+				rackNode("n2", "r1"),
+			},
+			want: map[string]string{"n1": ErrReasonExistingAntiAffinityRulesNotMatch, "n2": ""},
+		},
+		{
+			name: "existing pod's anti-affinity applies to a pod without affinity",
+			pod:  rolePod("ps-1", "ps").Obj(),
+			nodes: []*resource.NodeInfo{
+				rackNode("n1", "r1", rolePod("ps-0", "ps").PodAntiAffinity(corev1.LabelHostname, ps).Obj()),
+				rackNode("n2", "r1"),
+			},
+			want: map[string]string{"n1": ErrReasonExistingAntiAffinityRulesNotMatch, "n2": ""},
+		},
+		{
+			name:  "incoming pod's anti-affinity",
+			pod:   rolePod("ps-1", "ps").PodAntiAffinity(corev1.LabelHostname, ps).Obj(),
+			nodes: []*resource.NodeInfo{rackNode("n1", "r1", rolePod("ps-0", "ps").Obj()), rackNode("n2", "r1")},
+			want:  map[string]string{"n1": ErrReasonAntiAffinityRulesNotMatch, "n2": ""},
+		},
+		{
+			name: "affinity on a custom topology key co-locates in the rack",
+			pod:  rolePod("loader", "loader").PodAffinity(rackKey, trainer).Obj(),
+			nodes: []*resource.NodeInfo{
+				rackNode("n1", "r1", rolePod("trainer", "trainer").Obj()),
+				rackNode("n2", "r1"),
+				rackNode("n3", "r2"),
+				rackNode("n4", ""),
+			},
+			want: map[string]string{"n1": "", "n2": "", "n3": ErrReasonAffinityRulesNotMatch, "n4": ErrReasonAffinityRulesNotMatch},
+		},
+		{
+			name:  "affinity without a matching pod anywhere",
+			pod:   rolePod("loader", "loader").PodAffinity(rackKey, trainer).Obj(),
+			nodes: []*resource.NodeInfo{rackNode("n1", "r1"), rackNode("n2", "r2")},
+			want:  map[string]string{"n1": ErrReasonAffinityRulesNotMatch, "n2": ErrReasonAffinityRulesNotMatch},
+		},
+		{
+			name:  "first pod of a group matching its own affinity",
+			pod:   rolePod("trainer-0", "trainer").PodAffinity(rackKey, trainer).Obj(),
+			nodes: []*resource.NodeInfo{rackNode("n1", "r1"), rackNode("n2", "r2")},
+			want:  map[string]string{"n1": "", "n2": ""},
+		},
+		{
+			name: "pods of other namespaces do not match",
+			pod:  rolePod("loader", "loader").PodAffinity(rackKey, trainer).Obj(),
+			nodes: []*resource.NodeInfo{
+				rackNode("n1", "r1", rolePod("trainer", "trainer").Namespace("other").Obj()),
+				rackNode("n2", "r2"),
+			},
+			want: map[string]string{"n1": ErrReasonAffinityRulesNotMatch, "n2": ErrReasonAffinityRulesNotMatch},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := framework.NewCycleState()
+			state.SetSnapshot(tt.nodes)
+			pl := &InterPodAffinity{}
+			if status := pl.PreFilter(context.TODO(), state, tt.pod); !status.IsSuccess() {
+				t.Fatalf("PreFilter() = %v", status.Message())
+			}
+			for _, node := range tt.nodes {
+				status := pl.Filter(context.TODO(), state, tt.pod, node)
+				if got := status.Message(); got != tt.want[node.NodeName] {
+					t.Errorf("Filter(%s) = %q, want %q", node.NodeName, got, tt.want[node.NodeName])
+				}
+			}
+		})
+	}
+}
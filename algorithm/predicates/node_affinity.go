@@ -0,0 +1,61 @@
+package predicates
+
+import (
+	"context"
+	"fmt"
+
+	resource "gpu-scheduler/resourceinfo"
+	framework "gpu-scheduler/vlalpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+)
+
+const (
+	NodeAffinityName = "NodeAffinity"
+
+	// ErrReasonNodeAffinity is the reason of the nodes not matching the
+	// nodeSelector or the required node affinity of the pod.
+	ErrReasonNodeAffinity = "node(s) didn't match Pod's node affinity/selector"
+
+	nodeAffinityStateKey framework.StateKey = "PreFilter" + NodeAffinityName
+)
+
+// nodeAffinityState is the nodeSelector and required node affinity of the
+// pod, parsed once per attempt.
+type nodeAffinityState struct {
+	required nodeaffinity.RequiredNodeAffinity
+}
+
+// NodeAffinity filters the nodes that do not match the nodeSelector or the
+// requiredDuringSchedulingIgnoredDuringExecution node affinity of the pod,
+// both matchExpressions on the node labels and matchFields on metadata.name.
+type NodeAffinity struct{}
+
+var _ framework.PreFilterPlugin = &NodeAffinity{}
+var _ framework.FilterPlugin = &NodeAffinity{}
+
+func (pl *NodeAffinity) Name() string {
+	return NodeAffinityName
+}
+
+func (pl *NodeAffinity) PreFilter(ctx context.Context, state *framework.CycleState, newPod *corev1.Pod) *framework.Status {
+	state.Write(nodeAffinityStateKey, &nodeAffinityState{required: nodeaffinity.GetRequiredNodeAffinity(newPod)})
+	return nil
+}
+
+func (pl *NodeAffinity) Filter(ctx context.Context, state *framework.CycleState, newPod *corev1.Pod, nodeinfo *resource.NodeInfo) *framework.Status {
+	data, err := state.Read(nodeAffinityStateKey)
+	if err != nil {
+		return framework.AsStatus(fmt.Errorf("failed to read %q from cycle state,reason: %v", nodeAffinityStateKey, err))
+	}
+	s, ok := data.(*nodeAffinityState)
+	if !ok {
+		return framework.AsStatus(fmt.Errorf("%+v convert to predicates.nodeAffinityState error", data))
+	}
+	//잘못된 selector는 어떤 노드와도 맞지 않는 것으로 처리
+	if match, _ := s.required.Match(&nodeinfo.Node); !match {
+		return framework.NewStatus(framework.Unschedulable, ErrReasonNodeAffinity)
+	}
+	return nil
+}
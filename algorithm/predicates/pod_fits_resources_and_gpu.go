@@ -1,34 +1,113 @@
 package predicates
 
 import (
+	"context"
 	"fmt"
-	"gpu-scheduler/postevent"
+
 	resource "gpu-scheduler/resourceinfo"
+	framework "gpu-scheduler/vlalpha1"
 
 	corev1 "k8s.io/api/core/v1"
 )
 
-func PodFitsResourcesAndGPU(nodeInfoList []*resource.NodeInfo, newPod *corev1.Pod) error {
-	fmt.Println(" 1-1. PodFitsResourcesAndGPU")
+const (
+	PodFitsResourcesName = "PodFitsResources"
+	PodFitsGPUName       = "PodFitsGPU"
 
-	for _, nodeinfo := range nodeInfoList {
-		if !nodeinfo.IsFiltered {
-			if nodeinfo.NodeName == "temp" {
-				nodeinfo.FilterNode()
-			}
-		}
-	}
+	resourcesStateKey framework.StateKey = "PreFilter" + PodFitsResourcesName
+	gpuStateKey       framework.StateKey = "PreFilter" + PodFitsGPUName
+)
+
+// resourcesState is the request of the pod, computed once per attempt
+// instead of once per node.
+type resourcesState struct {
+	request *resource.Resource
+}
+
+// gpuState is the GPU request of the pod, computed once per attempt.
+type gpuState struct {
+	mpsRequest   int64
+	wholeRequest int64
+}
+
+// PodFitsResources checks CPU, memory and ephemeral-storage of the node
+// against the requests of its pods plus the new pod.
+type PodFitsResources struct{}
+
+var _ framework.PreFilterPlugin = &PodFitsResources{}
+var _ framework.FilterPlugin = &PodFitsResources{}
 
-	//no node to allocate
-	if *resource.AvailableNodeCount == 0 {
-		message := fmt.Sprintf("pod (%s) failed to fit in any node", newPod.ObjectMeta.Name)
-		event := postevent.MakeNoNodeEvent(newPod, message)
-		err := postevent.PostEvent(event)
-		if err != nil {
-			fmt.Println("podFitsResourcesAndGPU error: ", err)
-			return err
-		}
+func (pl *PodFitsResources) Name() string {
+	return PodFitsResourcesName
+}
+
+func (pl *PodFitsResources) PreFilter(ctx context.Context, state *framework.CycleState, newPod *corev1.Pod) *framework.Status {
+	state.Write(resourcesStateKey, &resourcesState{request: resource.GetPodResourceRequest(newPod)})
+	return nil
+}
+
+func (pl *PodFitsResources) Filter(ctx context.Context, state *framework.CycleState, newPod *corev1.Pod, nodeinfo *resource.NodeInfo) *framework.Status {
+	data, err := state.Read(resourcesStateKey)
+	if err != nil {
+		return framework.AsStatus(fmt.Errorf("failed to read %q from cycle state,reason: %v", resourcesStateKey, err))
+	}
+	s, ok := data.(*resourcesState)
+	if !ok {
+		return framework.AsStatus(fmt.Errorf("%+v convert to predicates.resourcesState error", data))
 	}
+	insufficient := insufficientResources(nodeinfo, s.request)
+	if len(insufficient) > 0 {
+		return framework.NewStatus(framework.Unschedulable, insufficient...)
+	}
+	return nil
+}
+
+// PodFitsGPU checks that the node has enough free GPUs for the pod.
+type PodFitsGPU struct{}
+
+var _ framework.PreFilterPlugin = &PodFitsGPU{}
+var _ framework.FilterPlugin = &PodFitsGPU{}
+
+func (pl *PodFitsGPU) Name() string {
+	return PodFitsGPUName
+}
+
+func (pl *PodFitsGPU) PreFilter(ctx context.Context, state *framework.CycleState, newPod *corev1.Pod) *framework.Status {
+	state.Write(gpuStateKey, &gpuState{
+		mpsRequest:   resource.GPURequest(newPod),
+		wholeRequest: resource.ExtendedResourceRequest(newPod, resource.NvidiaGPUResource),
+	})
+	return nil
+}
 
+func (pl *PodFitsGPU) Filter(ctx context.Context, state *framework.CycleState, newPod *corev1.Pod, nodeinfo *resource.NodeInfo) *framework.Status {
+	data, err := state.Read(gpuStateKey)
+	if err != nil {
+		return framework.AsStatus(fmt.Errorf("failed to read %q from cycle state,reason: %v", gpuStateKey, err))
+	}
+	s, ok := data.(*gpuState)
+	if !ok {
+		return framework.AsStatus(fmt.Errorf("%+v convert to predicates.gpuState error", data))
+	}
+	insufficient := insufficientGPU(nodeinfo, s.mpsRequest, s.wholeRequest)
+	if len(insufficient) > 0 {
+		return framework.NewStatus(framework.Unschedulable, insufficient...)
+	}
 	return nil
 }
+
+// insufficientResources returns the resources the node cannot provide on
+// top of what its pods already request.
+func insufficientResources(nodeinfo *resource.NodeInfo, podRequest *resource.Resource) []string {
+	insufficient := make([]string, 0)
+	if podRequest.MilliCPU > 0 && nodeinfo.Allocatable.MilliCPU < nodeinfo.Requested.MilliCPU+podRequest.MilliCPU {
+		insufficient = append(insufficient, "Insufficient "+string(corev1.ResourceCPU))
+	}
+	if podRequest.Memory > 0 && nodeinfo.Allocatable.Memory < nodeinfo.Requested.Memory+podRequest.Memory {
+		insufficient = append(insufficient, "Insufficient "+string(corev1.ResourceMemory))
+	}
+	if podRequest.EphemeralStorage > 0 && nodeinfo.Allocatable.EphemeralStorage < nodeinfo.Requested.EphemeralStorage+podRequest.EphemeralStorage {
+		insufficient = append(insufficient, "Insufficient "+string(corev1.ResourceEphemeralStorage))
+	}
+	return insufficient
+}
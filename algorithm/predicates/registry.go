@@ -0,0 +1,16 @@
+package predicates
+
+import (
+	framework "gpu-scheduler/vlalpha1"
+)
+
+// NewRegistry returns the filter plugins of this package.
+func NewRegistry() framework.Registry {
+	return framework.Registry{
+		PodFitsResourcesName: func() (framework.Plugin, error) { return &PodFitsResources{}, nil },
+		PodFitsGPUName:       func() (framework.Plugin, error) { return &PodFitsGPU{}, nil },
+		NodeAffinityName:     func() (framework.Plugin, error) { return &NodeAffinity{}, nil },
+		TaintTolerationName:  func() (framework.Plugin, error) { return &TaintToleration{}, nil },
+		InterPodAffinityName: func() (framework.Plugin, error) { return &InterPodAffinity{}, nil },
+	}
+}
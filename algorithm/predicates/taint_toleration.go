@@ -0,0 +1,34 @@
+package predicates
+
+import (
+	"context"
+	"fmt"
+
+	resource "gpu-scheduler/resourceinfo"
+	framework "gpu-scheduler/vlalpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+)
+
+const TaintTolerationName = "TaintToleration"
+
+// TaintToleration filters the nodes with a NoSchedule or NoExecute taint
+// the pod does not tolerate. PreferNoSchedule taints are ignored.
+type TaintToleration struct{}
+
+var _ framework.FilterPlugin = &TaintToleration{}
+
+func (pl *TaintToleration) Name() string {
+	return TaintTolerationName
+}
+
+func (pl *TaintToleration) Filter(ctx context.Context, state *framework.CycleState, newPod *corev1.Pod, nodeinfo *resource.NodeInfo) *framework.Status {
+	taint, untolerated := corev1helpers.FindMatchingUntoleratedTaint(nodeinfo.Node.Spec.Taints, newPod.Spec.Tolerations, func(t *corev1.Taint) bool {
+		return t.Effect == corev1.TaintEffectNoSchedule || t.Effect == corev1.TaintEffectNoExecute
+	})
+	if !untolerated {
+		return nil
+	}
+	return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("node(s) had taint {%s: %s}, that the pod didn't tolerate", taint.Key, taint.Value))
+}
@@ -0,0 +1,66 @@
+package predicates
+
+import (
+	"context"
+	"testing"
+
+	resource "gpu-scheduler/resourceinfo"
+	st "gpu-scheduler/testing"
+	framework "gpu-scheduler/vlalpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestTaintTolerationFilter(t *testing.T) {
+	const (
+		masterTaint       = "node-role.kubernetes.io/master"
+		controlPlaneTaint = "node-role.kubernetes.io/control-plane"
+	)
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		node *corev1.Node
+		want string //reason, "" if the node fits
+	}{
+		{
+			name: "master node",
+			pod:  st.MakePod().Name("p").Obj(),
+			node: st.MakeNode().Name("master").Taint(masterTaint, "", corev1.TaintEffectNoSchedule).Obj(),
+			want: "node(s) had taint {node-role.kubernetes.io/master: }, that the pod didn't tolerate",
+		},
+		{
+			name: "control plane node",
+			pod:  st.MakePod().Name("p").Obj(),
+			node: st.MakeNode().Name("control-plane").Taint(controlPlaneTaint, "", corev1.TaintEffectNoSchedule).Obj(),
+			want: "node(s) had taint {node-role.kubernetes.io/control-plane: }, that the pod didn't tolerate",
+		},
+		{
+			name: "pod tolerating the master taint",
+			pod:  st.MakePod().Name("p").Toleration(masterTaint).Obj(),
+			node: st.MakeNode().Name("master").Taint(masterTaint, "", corev1.TaintEffectNoSchedule).Obj(),
+		},
+		{
+			name: "PreferNoSchedule taint is ignored",
+			pod:  st.MakePod().Name("p").Obj(),
+			node: st.MakeNode().Name("master").Taint(masterTaint, "", corev1.TaintEffectPreferNoSchedule).Obj(),
+		},
+		{
+			name: "worker node",
+			pod:  st.MakePod().Name("p").Obj(),
+			node: st.MakeNode().Name("worker").Obj(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := &TaintToleration{}
+			status := pl.Filter(context.TODO(), framework.NewCycleState(), tt.pod, resource.NewNodeInfo(tt.node))
+			if got := status.Message(); got != tt.want {
+				t.Errorf("Filter() = %q, want %q", got, tt.want)
+			}
+			//TaintToleration 없이는 컨트롤 플레인 노드도 걸러지지 않음
+			if status := nodeStatus(resource.NewNodeInfo(tt.node)); !status.IsSuccess() {
+				t.Errorf("nodeStatus() = %q, want success", status.Message())
+			}
+		})
+	}
+}
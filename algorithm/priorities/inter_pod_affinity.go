@@ -0,0 +1,218 @@
+package priorities
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"gpu-scheduler/config"
+	"gpu-scheduler/parallelize"
+	resource "gpu-scheduler/resourceinfo"
+	framework "gpu-scheduler/vlalpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	InterPodAffinityName = "InterPodAffinity"
+
+	// hardPodAffinityWeight is the score a node gets for every existing pod
+	// whose required affinity matches the pod, so that pods are placed near
+	// the pods that need them even if they do not ask for it themselves.
+	hardPodAffinityWeight = 1
+
+	interPodAffinityStateKey framework.StateKey = "PreScore" + InterPodAffinityName
+)
+
+// scoreMap is the score of each topology domain: label key, then value.
+type scoreMap map[string]map[string]int64
+
+func (m scoreMap) processTerm(term *resource.AffinityTerm, weight int32, pod *corev1.Pod, node *corev1.Node, multiplier int32) {
+	if !term.Matches(pod) {
+		return
+	}
+	value, ok := node.Labels[term.TopologyKey]
+	if !ok {
+		return
+	}
+	if m[term.TopologyKey] == nil {
+		m[term.TopologyKey] = make(map[string]int64)
+	}
+	m[term.TopologyKey][value] += int64(weight * multiplier)
+}
+
+func (m scoreMap) processTerms(terms []resource.WeightedAffinityTerm, pod *corev1.Pod, node *corev1.Node, multiplier int32) {
+	for i := range terms {
+		m.processTerm(&terms[i].AffinityTerm, terms[i].Weight, pod, node, multiplier)
+	}
+}
+
+func (m scoreMap) append(other scoreMap) {
+	for key, values := range other {
+		if m[key] == nil {
+			m[key] = values
+			continue
+		}
+		for value, score := range values {
+			m[key][value] += score
+		}
+	}
+}
+
+// interPodAffinityState is the score of every topology domain, computed
+// once per attempt from the pods of all nodes, assumed pods included.
+type interPodAffinityState struct {
+	topologyScore scoreMap
+}
+
+// InterPodAffinity favors the nodes in the topology domains of the pods
+// matching the preferred pod affinity of the pod and disfavors those of the
+// pods matching its preferred anti-affinity. It is symmetric: the required
+// and preferred affinity and the preferred anti-affinity of the pods
+// already placed count for the pod as well.
+type InterPodAffinity struct{}
+
+var _ framework.PreScorePlugin = &InterPodAffinity{}
+var _ framework.ScorePlugin = &InterPodAffinity{}
+
+func (pl *InterPodAffinity) Name() string {
+	return InterPodAffinityName
+}
+
+func (pl *InterPodAffinity) PreScore(ctx context.Context, state *framework.CycleState, newPod *corev1.Pod, nodes []*resource.NodeInfo) *framework.Status {
+	var affinityTerms, antiAffinityTerms []resource.WeightedAffinityTerm
+	var err error
+	if affinity := newPod.Spec.Affinity; affinity != nil {
+		if affinity.PodAffinity != nil {
+			affinityTerms, err = resource.GetWeightedAffinityTerms(newPod, affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
+			if err != nil {
+				return framework.AsStatus(fmt.Errorf("failed to parse pod affinity of pod (%s),reason: %v", newPod.Name, err))
+			}
+		}
+		if affinity.PodAntiAffinity != nil {
+			antiAffinityTerms, err = resource.GetWeightedAffinityTerms(newPod, affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
+			if err != nil {
+				return framework.AsStatus(fmt.Errorf("failed to parse pod anti-affinity of pod (%s),reason: %v", newPod.Name, err))
+			}
+		}
+	}
+	hasConstraints := len(affinityTerms) > 0 || len(antiAffinityTerms) > 0
+
+	//필터링을 통과하지 못한 노드의 파드도 도메인 점수에 포함
+	s := &interPodAffinityState{topologyScore: make(scoreMap)}
+	var mu sync.Mutex
+	allNodes := state.Snapshot()
+	parallelize.Until(ctx, config.Parallelism, len(allNodes), func(i int) {
+		node := &allNodes[i].Node
+		topologyScore := make(scoreMap)
+		for _, existingPod := range allNodes[i].Pods {
+			//새 파드에 조건이 없으면 affinity가 있는 기존 파드만 점수에 영향
+			if !hasConstraints && !resource.HasPodAffinity(existingPod) {
+				continue
+			}
+			topologyScore.processTerms(affinityTerms, existingPod, node, 1)
+			topologyScore.processTerms(antiAffinityTerms, existingPod, node, -1)
+			processExistingPod(topologyScore, existingPod, newPod, node)
+		}
+		if len(topologyScore) == 0 {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		s.topologyScore.append(topologyScore)
+	})
+	if err := ctx.Err(); err != nil {
+		return framework.AsStatus(err)
+	}
+
+	state.Write(interPodAffinityStateKey, s)
+	return nil
+}
+
+// processExistingPod adds the affinity of the existing pod towards the
+// incoming pod to the domains of its node.
+func processExistingPod(topologyScore scoreMap, existingPod, newPod *corev1.Pod, node *corev1.Node) {
+	affinity := existingPod.Spec.Affinity
+	if affinity == nil {
+		return
+	}
+	//API 서버가 검증한 파드이므로 잘못된 term은 무시
+	if affinity.PodAffinity != nil {
+		if terms, err := resource.GetAffinityTerms(existingPod, affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution); err == nil {
+			for i := range terms {
+				topologyScore.processTerm(&terms[i], hardPodAffinityWeight, newPod, node, 1)
+			}
+		}
+		if terms, err := resource.GetWeightedAffinityTerms(existingPod, affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution); err == nil {
+			topologyScore.processTerms(terms, newPod, node, 1)
+		}
+	}
+	if affinity.PodAntiAffinity != nil {
+		if terms, err := resource.GetWeightedAffinityTerms(existingPod, affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution); err == nil {
+			topologyScore.processTerms(terms, newPod, node, -1)
+		}
+	}
+}
+
+// Score sums the scores of the domains the node is in. The sum can be
+// negative until it is normalized.
+func (pl *InterPodAffinity) Score(ctx context.Context, state *framework.CycleState, newPod *corev1.Pod, nodeinfo *resource.NodeInfo) (float64, *framework.Status) {
+	s, err := getInterPodAffinityState(state)
+	if err != nil {
+		return 0, framework.AsStatus(err)
+	}
+	var score int64
+	for key, values := range s.topologyScore {
+		if value, ok := nodeinfo.Node.Labels[key]; ok {
+			score += values[value]
+		}
+	}
+	return float64(score), nil
+}
+
+func (pl *InterPodAffinity) ScoreExtensions() framework.ScoreExtensions {
+	return pl
+}
+
+// NormalizeScore scales the scores between the lowest and the highest one
+// to 0-100.
+func (pl *InterPodAffinity) NormalizeScore(ctx context.Context, state *framework.CycleState, newPod *corev1.Pod, scores framework.NodeScoreList) *framework.Status {
+	s, err := getInterPodAffinityState(state)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	if len(s.topologyScore) == 0 || len(scores) == 0 {
+		return nil
+	}
+
+	minScore, maxScore := scores[0].Score, scores[0].Score
+	for _, score := range scores {
+		if score.Score < minScore {
+			minScore = score.Score
+		}
+		if score.Score > maxScore {
+			maxScore = score.Score
+		}
+	}
+	for i := range scores {
+		if maxScore == minScore {
+			scores[i].Score = 0
+			continue
+		}
+		scores[i].Score = MaxNodeScore * (scores[i].Score - minScore) / (maxScore - minScore)
+	}
+	return nil
+}
+
+func getInterPodAffinityState(state *framework.CycleState) (*interPodAffinityState, error) {
+	data, err := state.Read(interPodAffinityStateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q from cycle state,reason: %v", interPodAffinityStateKey, err)
+	}
+	s, ok := data.(*interPodAffinityState)
+	if !ok {
+		return nil, fmt.Errorf("%+v convert to priorities.interPodAffinityState error", data)
+	}
+	return s, nil
+}
@@ -0,0 +1,120 @@
+package priorities
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	resource "gpu-scheduler/resourceinfo"
+	st "gpu-scheduler/testing"
+	framework "gpu-scheduler/vlalpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const rackKey = "gpu-rack"
+
+func rackNode(name, rack string, pods ...*corev1.Pod) *resource.NodeInfo {
+	return resource.NewNodeInfo(st.MakeNode().Name(name).Label(rackKey, rack).Obj(), pods...)
+}
+
+func rolePod(name, role string) *st.PodWrapper {
+	return st.MakePod().Namespace("ml").Name(name).Label("role", role)
+}
+
+func TestInterPodAffinityScore(t *testing.T) {
+	trainer := map[string]string{"role": "trainer"}
+	loader := map[string]string{"role": "loader"}
+
+	tests := []struct {
+		name  string
+		pod   *corev1.Pod
+		nodes []*resource.NodeInfo
+		raw   []float64
+		want  []float64
+	}{
+		{
+			name:  "preferred affinity favors the trainer's rack",
+			pod:   rolePod("loader", "loader").PreferredPodAffinity(10, rackKey, trainer).Obj(),
+			nodes: []*resource.NodeInfo{rackNode("n1", "r1", rolePod("trainer", "trainer").Obj()), rackNode("n2", "r1"), rackNode("n3", "r2")},
+			raw:   []float64{10, 10, 0},
+			want:  []float64{100, 100, 0},
+		},
+		{
+			name:  "preferred anti-affinity disfavors the trainer's rack",
+			pod:   rolePod("loader", "loader").PreferredPodAntiAffinity(10, rackKey, trainer).Obj(),
+			nodes: []*resource.NodeInfo{rackNode("n1", "r1", rolePod("trainer", "trainer").Obj()), rackNode("n2", "r2")},
+			raw:   []float64{-10, 0},
+			want:  []float64{0, 100},
+		},
+		{
+			name: "negative sums are scaled from the lowest one",
+			pod: rolePod("loader", "loader").
+				PreferredPodAntiAffinity(10, rackKey, trainer).
+				PreferredPodAntiAffinity(5, corev1.LabelHostname, trainer).Obj(),
+			nodes: []*resource.NodeInfo{
+				rackNode("n1", "r1", rolePod("trainer-0", "trainer").Obj(), rolePod("trainer-1", "trainer").Obj()),
+				rackNode("n2", "r1"),
+				rackNode("n3", "r2", rolePod("trainer-2", "trainer").Obj()),
+			},
+			raw:  []float64{-30, -20, -15},
+			want: []float64{0, 100 * 10.0 / 15, 100},
+		},
+		{
+			name:  "existing pod's required affinity counts for the pod",
+			pod:   rolePod("loader", "loader").Obj(),
+			nodes: []*resource.NodeInfo{rackNode("n1", "r1", rolePod("trainer", "trainer").PodAffinity(rackKey, loader).Obj()), rackNode("n2", "r2")},
+			raw:   []float64{hardPodAffinityWeight, 0},
+			want:  []float64{100, 0},
+		},
+		{
+			name:  "equal sums score every node zero",
+			pod:   rolePod("loader", "loader").PreferredPodAffinity(10, rackKey, trainer).Obj(),
+			nodes: []*resource.NodeInfo{rackNode("n1", "r1", rolePod("trainer", "trainer").Obj()), rackNode("n2", "r1")},
+			raw:   []float64{10, 10},
+			want:  []float64{0, 0},
+		},
+		{
+			name:  "no affinity scores every node zero",
+			pod:   rolePod("loader", "loader").Obj(),
+			nodes: []*resource.NodeInfo{rackNode("n1", "r1", rolePod("trainer", "trainer").Obj()), rackNode("n2", "r2")},
+			raw:   []float64{0, 0},
+			want:  []float64{0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := framework.NewCycleState()
+			state.SetSnapshot(tt.nodes)
+			pl := &InterPodAffinity{}
+			if status := pl.PreScore(context.TODO(), state, tt.pod, tt.nodes); !status.IsSuccess() {
+				t.Fatalf("PreScore() = %v", status.Message())
+			}
+
+			scores := make(framework.NodeScoreList, 0, len(tt.nodes))
+			raw := make([]float64, 0, len(tt.nodes))
+			for _, node := range tt.nodes {
+				score, status := pl.Score(context.TODO(), state, tt.pod, node)
+				if !status.IsSuccess() {
+					t.Fatalf("Score(%s) = %v", node.NodeName, status.Message())
+				}
+				raw = append(raw, score)
+				scores = append(scores, framework.NodeScore{Name: node.NodeName, Score: score})
+			}
+			if !reflect.DeepEqual(raw, tt.raw) {
+				t.Errorf("Score() = %v, want %v", raw, tt.raw)
+			}
+
+			if status := pl.NormalizeScore(context.TODO(), state, tt.pod, scores); !status.IsSuccess() {
+				t.Fatalf("NormalizeScore() = %v", status.Message())
+			}
+			got := make([]float64, 0, len(scores))
+			for _, score := range scores {
+				got = append(got, score.Score)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalized scores = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -14,22 +14,88 @@
 package priorities
 
 import (
-	"fmt"
+	"sort"
 
 	resource "gpu-scheduler/resourceinfo"
+
+	corev1 "k8s.io/api/core/v1"
 )
 
-func MetricBasedScoring(nodeInfoList []*resource.NodeInfo) error {
+const (
+	MaxNodeScore = 100
+
+	//GPU 점수 가중치 (합계 1)
+	freeMemoryWeight  = 0.4
+	utilizationWeight = 0.4
+	mpsClientWeight   = 0.2
+)
+
+// MetricBasedScoring scores nodes from live GPU metrics. A GPU pod is scored
+// by the devices it would get on the node: free GPU memory, idle SM time and
+// free MPS slots. A pod without GPUs is scored by node CPU and memory usage.
+// A node whose metric is missing or empty scores 0 for every pod, so that a
+// node with no data never looks idle.
+func MetricBasedScoring(nodeinfo *resource.NodeInfo, newPod *corev1.Pod) float64 {
+	if nodeinfo.Metric == nil || nodeinfo.Metric.IsEmpty() {
+		return 0
+	}
+	gpuReq := int(resource.GPURequest(newPod))
+	if gpuReq == 0 {
+		return nodeUsageScore(nodeinfo.Metric)
+	}
+	return gpuScore(nodeinfo, gpuReq)
+}
+
+// gpuScore averages the scores of the best gpuReq devices with a free MPS slot.
+func gpuScore(nodeinfo *resource.NodeInfo, gpuReq int) float64 {
+	scores := make([]float64, 0)
+	for _, device := range resource.Allocator.Devices(nodeinfo.NodeName) {
+		if device.FreeSlots() <= 0 {
+			continue
+		}
+		scores = append(scores, deviceScore(device, nodeinfo.Metric.GPUs[device.UUID]))
+	}
+	if len(scores) < gpuReq {
+		return 0
+	}
 
-	fmt.Println(" 2-1. MetricBasedScoring")
+	sort.Sort(sort.Reverse(sort.Float64Slice(scores)))
+	var sum float64
+	for _, score := range scores[:gpuReq] {
+		sum += score
+	}
+	return sum / float64(gpuReq)
+}
 
-	for _, nodeinfo := range nodeInfoList {
-		if !nodeinfo.IsFiltered {
-			if nodeinfo.NodeName == "gpuserver2" {
-				nodeinfo.NodeScore = 50
-			}
+func deviceScore(device resource.GPUDevice, gpuMetric *resource.GPUMetric) float64 {
+	freeMemory, idle := 0.0, 0.0
+	if gpuMetric != nil {
+		if gpuMetric.MemoryTotal > 0 {
+			freeMemory = float64(gpuMetric.MemoryFree()) / float64(gpuMetric.MemoryTotal)
 		}
+		idle = 1 - clamp(gpuMetric.Utilization/100)
 	}
+	freeSlots := 0.0
+	if device.MPSSlots > 0 {
+		freeSlots = float64(device.FreeSlots()) / float64(device.MPSSlots)
+	}
+
+	score := freeMemoryWeight*freeMemory + utilizationWeight*idle + mpsClientWeight*clamp(freeSlots)
+	return score * MaxNodeScore
+}
+
+func nodeUsageScore(metric *resource.NodeMetric) float64 {
+	idleCPU := 1 - clamp(metric.NodeCPU/100)
+	freeMemory := 1 - clamp(metric.NodeMemory/100)
+	return (idleCPU + freeMemory) / 2 * MaxNodeScore
+}
 
-	return nil
+func clamp(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
 }
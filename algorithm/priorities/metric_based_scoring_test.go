@@ -0,0 +1,57 @@
+package priorities
+
+import (
+	"math"
+	"testing"
+
+	"gpu-scheduler/config"
+	resource "gpu-scheduler/resourceinfo"
+	st "gpu-scheduler/testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestMetricBasedScoring(t *testing.T) {
+	defer func(slots int) { config.MPSClientsPerGPU = slots }(config.MPSClientsPerGPU)
+	config.MPSClientsPerGPU = 4
+
+	gpuMetric := &resource.NodeMetric{
+		NodeName:   "score-node",
+		NodeCPU:    50,
+		NodeMemory: 50,
+		GPUCount:   1,
+		UUIDs:      []string{"gpu-0"},
+		GPUs: map[string]*resource.GPUMetric{
+			"gpu-0": {UUID: "gpu-0", Utilization: 50, MemoryUsed: 250, MemoryTotal: 1000},
+		},
+	}
+	cpuPod := st.MakePod().Name("cpu").Req(map[corev1.ResourceName]string{corev1.ResourceCPU: "1"}).Obj()
+	gpuPod := st.MakePod().Name("gpu").Req(map[corev1.ResourceName]string{resource.MPSGPUResource: "1"}).Obj()
+
+	tests := []struct {
+		name   string
+		metric *resource.NodeMetric
+		pod    *corev1.Pod
+		want   float64
+	}{
+		{name: "pod without GPUs on a half busy node", metric: gpuMetric, pod: cpuPod, want: 50},
+		//0.4*0.75 메모리 + 0.4*0.5 유휴 + 0.2*1 MPS 슬롯
+		{name: "GPU pod by its device", metric: gpuMetric, pod: gpuPod, want: 70},
+		{name: "missing metric for a pod without GPUs", pod: cpuPod, want: 0},
+		{name: "missing metric for a GPU pod", pod: gpuPod, want: 0},
+		{name: "empty metric for a pod without GPUs", metric: &resource.NodeMetric{NodeName: "score-node", UUIDs: []string{}}, pod: cpuPod, want: 0},
+		{name: "empty metric for a GPU pod", metric: &resource.NodeMetric{NodeName: "score-node", UUIDs: []string{}}, pod: gpuPod, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//GPU 목록은 마지막으로 성공한 메트릭에서 가져옴
+			resource.Allocator.UpdateNode("score-node", gpuMetric)
+			nodeInfo := resource.NewNodeInfo(st.MakeNode().Name("score-node").Obj())
+			nodeInfo.Metric = tt.metric
+
+			if got := MetricBasedScoring(nodeInfo, tt.pod); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("MetricBasedScoring() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package priorities
+
+import (
+	resource "gpu-scheduler/resourceinfo"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+)
+
+// NodeAffinity favors the nodes matching the preferredDuringScheduling
+// node affinity terms of the pod, by the sum of the weights of the terms
+// they match.
+func NodeAffinity(nodeinfo *resource.NodeInfo, newPod *corev1.Pod) float64 {
+	affinity := newPod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || len(affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution) == 0 {
+		return 0
+	}
+	terms, err := nodeaffinity.NewPreferredSchedulingTerms(affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
+	if err != nil {
+		//잘못된 term이 있으면 선호도를 적용하지 않음
+		return 0
+	}
+	return float64(terms.Score(&nodeinfo.Node))
+}
@@ -0,0 +1,120 @@
+package priorities
+
+import (
+	"context"
+	"fmt"
+
+	"gpu-scheduler/config"
+	resource "gpu-scheduler/resourceinfo"
+	framework "gpu-scheduler/vlalpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PriorityFunction returns the score of a node for the pod, from 0 to
+// MaxNodeScore unless the priority is in rawScorePriorities.
+type PriorityFunction func(nodeinfo *resource.NodeInfo, newPod *corev1.Pod) float64
+
+var Registry = map[string]PriorityFunction{
+	"MetricBasedScoring": MetricBasedScoring,
+	"MostAllocated":      MostAllocated,
+	"LeastAllocated":     LeastAllocated,
+	"NodeAffinity":       NodeAffinity,
+}
+
+// rawScorePriorities return unbounded sums, e.g. of term weights, that are
+// normalized to 0-100 across nodes before they are weighted.
+var rawScorePriorities = map[string]bool{
+	"NodeAffinity": true,
+}
+
+// scorePlugins are the priorities that look at all nodes at once, written
+// as score plugins instead of a PriorityFunction.
+var scorePlugins = framework.Registry{
+	InterPodAffinityName: func() (framework.Plugin, error) { return &InterPodAffinity{}, nil },
+}
+
+// priorityPlugin runs a PriorityFunction as a score plugin.
+type priorityPlugin struct {
+	name      string
+	function  PriorityFunction
+	normalize bool
+}
+
+var _ framework.ScorePlugin = &priorityPlugin{}
+
+func (pl *priorityPlugin) Name() string {
+	return pl.name
+}
+
+func (pl *priorityPlugin) Score(ctx context.Context, state *framework.CycleState, newPod *corev1.Pod, nodeinfo *resource.NodeInfo) (float64, *framework.Status) {
+	return pl.function(nodeinfo, newPod), nil
+}
+
+func (pl *priorityPlugin) ScoreExtensions() framework.ScoreExtensions {
+	//0~100 점수는 그대로 사용해야 가중치가 설정한 의미를 가짐
+	if !pl.normalize {
+		return nil
+	}
+	return pl
+}
+
+func (pl *priorityPlugin) NormalizeScore(ctx context.Context, state *framework.CycleState, newPod *corev1.Pod, scores framework.NodeScoreList) *framework.Status {
+	NormalizeScores(scores)
+	return nil
+}
+
+// PluginName returns the name a priority is registered under in the
+// framework registry. It differs from the priority name so that a priority
+// can share its name with a predicate, like NodeAffinity.
+func PluginName(name string) string {
+	return name + "Priority"
+}
+
+// NewRegistry returns a score plugin for every priority function.
+func NewRegistry() framework.Registry {
+	registry := framework.Registry{}
+	for name, function := range Registry {
+		pl := &priorityPlugin{name: name, function: function, normalize: rawScorePriorities[name]}
+		registry[PluginName(name)] = func() (framework.Plugin, error) { return pl, nil }
+	}
+	for name, factory := range scorePlugins {
+		registry[PluginName(name)] = factory
+	}
+	return registry
+}
+
+// PriorityWeights returns the score plugins to enable for the weighted
+// priorities of a profile.
+func PriorityWeights(weights []config.PriorityWeight) ([]framework.PluginConfig, error) {
+	pluginConfigs := make([]framework.PluginConfig, 0, len(weights))
+	for _, weight := range weights {
+		_, isFunction := Registry[weight.Name]
+		_, isPlugin := scorePlugins[weight.Name]
+		if !isFunction && !isPlugin {
+			return nil, fmt.Errorf("unknown priority %q", weight.Name)
+		}
+		pluginConfigs = append(pluginConfigs, framework.PluginConfig{Name: PluginName(weight.Name), Weight: weight.Weight})
+	}
+	if len(pluginConfigs) == 0 {
+		return nil, fmt.Errorf("no priority is enabled")
+	}
+	return pluginConfigs, nil
+}
+
+// NormalizeScores scales the scores so the highest one becomes MaxNodeScore.
+func NormalizeScores(scores framework.NodeScoreList) {
+	var maxScore float64
+	for _, score := range scores {
+		if score.Score > maxScore {
+			maxScore = score.Score
+		}
+	}
+	for i := range scores {
+		if maxScore == 0 {
+			scores[i].Score = 0
+			continue
+		}
+		scores[i].Score = scores[i].Score * MaxNodeScore / maxScore
+	}
+}
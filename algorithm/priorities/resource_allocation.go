@@ -0,0 +1,52 @@
+package priorities
+
+import (
+	resource "gpu-scheduler/resourceinfo"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// MostAllocated favors nodes that would be fuller after placing the pod, to
+// pack pods onto as few nodes and GPUs as possible.
+func MostAllocated(nodeinfo *resource.NodeInfo, newPod *corev1.Pod) float64 {
+	return allocatedFraction(nodeinfo, newPod) * MaxNodeScore
+}
+
+// LeastAllocated favors nodes that would be emptier after placing the pod, to
+// spread pods across nodes and GPUs.
+func LeastAllocated(nodeinfo *resource.NodeInfo, newPod *corev1.Pod) float64 {
+	return (1 - allocatedFraction(nodeinfo, newPod)) * MaxNodeScore
+}
+
+// allocatedFraction averages the requested/allocatable ratio of CPU, memory
+// and, on GPU nodes, MPS slots with the pod placed on the node.
+func allocatedFraction(nodeinfo *resource.NodeInfo, newPod *corev1.Pod) float64 {
+	podRequest := resource.GetPodResourceRequest(newPod)
+	fractions := []float64{
+		ratio(nodeinfo.Requested.MilliCPU+podRequest.MilliCPU, nodeinfo.Allocatable.MilliCPU),
+		ratio(nodeinfo.Requested.Memory+podRequest.Memory, nodeinfo.Allocatable.Memory),
+	}
+
+	devices := resource.Allocator.Devices(nodeinfo.NodeName)
+	if len(devices) > 0 {
+		var used, slots int64
+		for _, device := range devices {
+			used += int64(device.MPSClients)
+			slots += int64(device.MPSSlots)
+		}
+		fractions = append(fractions, ratio(used+resource.GPURequest(newPod), slots))
+	}
+
+	var sum float64
+	for _, fraction := range fractions {
+		sum += fraction
+	}
+	return sum / float64(len(fractions))
+}
+
+func ratio(requested, capacity int64) float64 {
+	if capacity <= 0 {
+		return 1
+	}
+	return clamp(float64(requested) / float64(capacity))
+}
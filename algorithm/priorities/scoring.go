@@ -14,65 +14,79 @@
 package priorities
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
+	"sync/atomic"
 
+	"gpu-scheduler/config"
+	"gpu-scheduler/logging"
 	resource "gpu-scheduler/resourceinfo"
+	framework "gpu-scheduler/vlalpha1"
 
 	corev1 "k8s.io/api/core/v1"
 )
 
+var roundRobinCounter uint64
+
 type NodePrice struct {
 	BestNode  *resource.NodeInfo
 	NodeScore float64
 }
 
-func Scoring(nodeInfoList []*resource.NodeInfo, newPod *corev1.Pod) (*resource.NodeInfo, error) {
-	fmt.Println("2. Scoring Stage")
-
-	var bestPriceNode *NodePrice
+func Scoring(ctx context.Context, fwk *framework.Framework, state *framework.CycleState, tieBreak string, nodeInfoList []*resource.NodeInfo, newPod *corev1.Pod) (*resource.NodeInfo, error) {
+	logger := logging.FromContext(ctx)
 
-	//debugging
-	fmt.Print("-Before Scoring Nodes")
+	feasibleNodes := make([]*resource.NodeInfo, 0, len(nodeInfoList))
 	for _, nodeinfo := range nodeInfoList {
 		if !nodeinfo.IsFiltered {
-			fmt.Print(" | ", nodeinfo.NodeName, "=", nodeinfo.NodeScore)
+			feasibleNodes = append(feasibleNodes, nodeinfo)
 		}
 	}
-	fmt.Println()
 
-	err := MetricBasedScoring(nodeInfoList)
-	if err != nil {
-		fmt.Println("scoring>metricBasedScoring error: ", err)
-		return &resource.NodeInfo{}, err
+	//필터링을 통과한 노드가 없으면 바인딩하지 않음
+	if len(feasibleNodes) == 0 {
+		return nil, fmt.Errorf("no node passed filtering for pod (%s)", newPod.ObjectMeta.Name)
 	}
 
-	//debugging
-	fmt.Print("-After Scoring Nodes")
-	for _, nodeinfo := range nodeInfoList {
-		if !nodeinfo.IsFiltered {
-			fmt.Print(" | ", nodeinfo.NodeName, "=", nodeinfo.NodeScore)
-		}
+	//스코어링 전에 노드 전체를 보고 계산할 값을 준비
+	if status := fwk.RunPreScorePlugins(ctx, state, newPod, feasibleNodes); !status.IsSuccess() {
+		return nil, status.AsError()
 	}
-	fmt.Println()
 
-	for _, nodeinfo := range nodeInfoList {
-		if !nodeinfo.IsFiltered {
-			if bestPriceNode == nil {
-				bestPriceNode = &NodePrice{nodeinfo, nodeinfo.NodeScore}
-				continue
-			}
-			if nodeinfo.NodeScore > bestPriceNode.NodeScore {
-				bestPriceNode.BestNode = nodeinfo
-				bestPriceNode.NodeScore = nodeinfo.NodeScore
-			}
-		}
+	//스코어 플러그인별 0~100 점수(원시 합계는 정규화)에 가중치를 곱해 합산
+	scores, status := fwk.RunScorePlugins(ctx, state, newPod, feasibleNodes)
+	if !status.IsSuccess() {
+		return nil, status.AsError()
 	}
-
-	if bestPriceNode == nil {
-		bestPriceNode = &NodePrice{nodeInfoList[0], 0}
+	v := logger.V(4)
+	for i, nodeinfo := range feasibleNodes {
+		nodeinfo.NodeScore = scores[i].Score
+		v.Info("Scored node", "node", nodeinfo.NodeName, "score", nodeinfo.NodeScore)
 	}
 
-	fmt.Println("BestNode: ", bestPriceNode.BestNode.NodeName)
+	bestNodes := make([]*resource.NodeInfo, 0)
+	for _, nodeinfo := range feasibleNodes {
+		if len(bestNodes) == 0 || nodeinfo.NodeScore > bestNodes[0].NodeScore {
+			bestNodes = []*resource.NodeInfo{nodeinfo}
+		} else if nodeinfo.NodeScore == bestNodes[0].NodeScore {
+			bestNodes = append(bestNodes, nodeinfo)
+		}
+	}
+	bestPriceNode := &NodePrice{selectTieBreak(tieBreak, bestNodes), bestNodes[0].NodeScore}
+	logger.V(2).Info("Selected node", "node", bestPriceNode.BestNode.NodeName, "score", bestPriceNode.NodeScore, "candidates", len(bestNodes))
 
 	return bestPriceNode.BestNode, nil
 }
+
+// selectTieBreak picks one of the nodes sharing the highest score.
+func selectTieBreak(tieBreak string, bestNodes []*resource.NodeInfo) *resource.NodeInfo {
+	if len(bestNodes) == 1 {
+		return bestNodes[0]
+	}
+	if tieBreak == config.RoundRobinTieBreak {
+		n := atomic.AddUint64(&roundRobinCounter, 1)
+		return bestNodes[(n-1)%uint64(len(bestNodes))]
+	}
+	return bestNodes[rand.Intn(len(bestNodes))]
+}
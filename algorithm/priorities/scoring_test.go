@@ -0,0 +1,126 @@
+package priorities
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"gpu-scheduler/config"
+	resource "gpu-scheduler/resourceinfo"
+	framework "gpu-scheduler/vlalpha1"
+)
+
+func TestNormalizeScores(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores []float64
+		want   []float64
+	}{
+		{
+			name:   "highest score becomes MaxNodeScore",
+			scores: []float64{10, 20, 40},
+			want:   []float64{25, 50, 100},
+		},
+		{
+			name:   "all scores equal",
+			scores: []float64{5, 5, 5},
+			want:   []float64{100, 100, 100},
+		},
+		{
+			name:   "all scores zero",
+			scores: []float64{0, 0},
+			want:   []float64{0, 0},
+		},
+		{
+			name:   "single node",
+			scores: []float64{0.3},
+			want:   []float64{100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores := make(framework.NodeScoreList, len(tt.scores))
+			for i, score := range tt.scores {
+				scores[i] = framework.NodeScore{Name: fmt.Sprintf("node-%d", i), Score: score}
+			}
+			NormalizeScores(scores)
+
+			got := make([]float64, len(scores))
+			for i, score := range scores {
+				got[i] = score.Score
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeScores() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriorityWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []config.PriorityWeight
+		wantErr bool
+	}{
+		{
+			name:    "registered priorities",
+			weights: []config.PriorityWeight{{Name: "MostAllocated", Weight: 1}, {Name: "MetricBasedScoring", Weight: 2}},
+		},
+		{
+			name:    "unknown priority",
+			weights: []config.PriorityWeight{{Name: "Foo", Weight: 1}},
+			wantErr: true,
+		},
+		{
+			name:    "no priority",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PriorityWeights(tt.weights)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("PriorityWeights() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PriorityWeights() error = %v", err)
+			}
+			if len(got) != len(tt.weights) {
+				t.Fatalf("PriorityWeights() = %v, want %d plugins", got, len(tt.weights))
+			}
+			for i, weight := range tt.weights {
+				if got[i].Name != PluginName(weight.Name) || got[i].Weight != weight.Weight {
+					t.Errorf("PriorityWeights()[%d] = %v, want %v", i, got[i], weight)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectTieBreakRoundRobin(t *testing.T) {
+	roundRobinCounter = 0
+
+	bestNodes := []*resource.NodeInfo{{NodeName: "node-1"}, {NodeName: "node-2"}, {NodeName: "node-3"}}
+	want := []string{"node-1", "node-2", "node-3", "node-1", "node-2"}
+	for i, name := range want {
+		if got := selectTieBreak(config.RoundRobinTieBreak, bestNodes); got.NodeName != name {
+			t.Errorf("selectTieBreak() #%d = %s, want %s", i, got.NodeName, name)
+		}
+	}
+}
+
+func TestSelectTieBreakRandom(t *testing.T) {
+	if got := selectTieBreak(config.RandomTieBreak, []*resource.NodeInfo{{NodeName: "node-1"}}); got.NodeName != "node-1" {
+		t.Errorf("selectTieBreak() with one node = %s, want node-1", got.NodeName)
+	}
+
+	bestNodes := []*resource.NodeInfo{{NodeName: "node-1"}, {NodeName: "node-2"}}
+	for i := 0; i < 20; i++ {
+		if got := selectTieBreak(config.RandomTieBreak, bestNodes); got != bestNodes[0] && got != bestNodes[1] {
+			t.Fatalf("selectTieBreak() = %s, not one of the tied nodes", got.NodeName)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Options selects the cluster the scheduler talks to. With no kubeconfig
+// and no master the in-cluster service account is used.
+type Options struct {
+	Kubeconfig string
+	Context    string
+	Master     string
+	QPS        float32
+	Burst      int
+}
+
+func NewOptions() *Options {
+	return &Options{
+		QPS:   50,
+		Burst: 100,
+	}
+}
+
+// RestConfig builds the rest config from the options.
+func (o *Options) RestConfig() (*rest.Config, error) {
+	var restConfig *rest.Config
+	var err error
+	if o.Kubeconfig == "" && o.Master == "" && o.Context == "" {
+		restConfig, err = rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load in-cluster config, set --kubeconfig or --master to run outside the cluster,reason: %v", err)
+		}
+	} else {
+		//--kubeconfig가 비어 있으면 KUBECONFIG, ~/.kube/config 순서로 찾음
+		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+		loadingRules.ExplicitPath = o.Kubeconfig
+		overrides := &clientcmd.ConfigOverrides{CurrentContext: o.Context}
+		overrides.ClusterInfo.Server = o.Master
+		restConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubeconfig,reason: %v", err)
+		}
+	}
+
+	restConfig.QPS = o.QPS
+	restConfig.Burst = o.Burst
+	restConfig.UserAgent = rest.DefaultKubernetesUserAgent()
+	return restConfig, nil
+}
+
+// NewClientset builds the clientset shared by every package of the scheduler.
+func (o *Options) NewClientset() (kubernetes.Interface, error) {
+	restConfig, err := o.RestConfig()
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset,reason: %v", err)
+	}
+	return clientset, nil
+}
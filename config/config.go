@@ -1,13 +1,13 @@
 package config
 
-var (
-	ApiHost             = "10.0.5.21:6443"
-	BindingsEndpoint    = "/api/v1/namespaces/%s/pods/%s/binding/"
-	AnnotationsEndpoint = "/api/v1/namespaces/%s/pods/%s"
-	EventsEndpoint      = "/api/v1/namespaces/%s/events"
-	NodesEndpoint       = "/api/v1/nodes"
-	PodsEndpoint        = "/api/v1/pods"
-	WatchPodsEndpoint   = "/api/v1/watch/pods"
-)
+// GPU 하나를 공유할 수 있는 최대 MPS 클라이언트 수, 설정 파일의 mpsClientsPerGPU로 덮어씀
+var MPSClientsPerGPU = 4
 
+// 노드 필터링/스코어링과 메트릭 조회에 사용하는 고루틴 수, 설정 파일의 parallelism으로 덮어씀
+var Parallelism = 16
+
+// 스코어링할 노드 비율(%), 0이면 클러스터 크기에 따라 자동 결정, 설정 파일의 percentageOfNodesToScore로 덮어씀
+var PercentageOfNodesToScore = 0
+
+// 설정 파일이 없을 때 사용하는 기본 프로파일 이름
 const SchedulerName = "gpu-scheduler"
@@ -0,0 +1,363 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	APIVersion = "gpu-scheduler.keti.com/v1alpha1"
+	Kind       = "GPUSchedulerConfiguration"
+)
+
+const (
+	InfluxDBMetricsProvider   = "influxdb"
+	PrometheusMetricsProvider = "prometheus"
+	StaticMetricsProvider     = "static"
+
+	RandomTieBreak     = "random"
+	RoundRobinTieBreak = "round-robin"
+)
+
+// SchedulerConfiguration is the configuration file of the scheduler.
+//
+//	apiVersion: gpu-scheduler.keti.com/v1alpha1
+//	kind: GPUSchedulerConfiguration
+//	reconcileInterval: 30s
+//	podInitialBackoff: 1s
+//	podMaxBackoff: 10s
+//	metricsBindAddress: :10251
+//	parallelism: 16
+//	percentageOfNodesToScore: 0
+//	leaderElection:
+//	  leaderElect: true
+//	  leaseDuration: 15s
+//	profiles:
+//	- schedulerName: mps-scheduler
+//	  predicates: [NodeAffinity, TaintToleration, InterPodAffinity, PodFitsResources, PodFitsGPU]
+//	  priorities:
+//	  - name: MetricBasedScoring
+//	    weight: 2
+//	  metrics:
+//	    provider: influxdb
+//	    influxdb:
+//	      url: http://influxdb.gpu.svc.cluster.local:8086
+//	      database: multimetric
+type SchedulerConfiguration struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+
+	// ReconcileInterval is how often pending pods missed by the watch are
+	// added to the scheduling queue.
+	ReconcileInterval metav1.Duration `json:"reconcileInterval"`
+	// PodInitialBackoff is the backoff of a pod after its first failed
+	// attempt. It doubles on every failure up to PodMaxBackoff.
+	PodInitialBackoff metav1.Duration `json:"podInitialBackoff"`
+	PodMaxBackoff     metav1.Duration `json:"podMaxBackoff"`
+	// MPSClientsPerGPU is the number of pods that can share one GPU through MPS.
+	MPSClientsPerGPU int `json:"mpsClientsPerGPU"`
+	// Parallelism is the number of goroutines filtering and scoring nodes
+	// and querying node metrics.
+	Parallelism int `json:"parallelism"`
+	// PercentageOfNodesToScore stops filtering once this share of the nodes
+	// is found feasible, on clusters of more than 100 nodes. 0 picks the
+	// share from the cluster size, 100 filters every node.
+	PercentageOfNodesToScore int `json:"percentageOfNodesToScore"`
+
+	LeaderElection LeaderElection `json:"leaderElection"`
+
+	// MetricsBindAddress is the address of the HTTP server of /metrics,
+	// /healthz, /readyz and /debug.
+	MetricsBindAddress string `json:"metricsBindAddress"`
+
+	Profiles []Profile `json:"profiles"`
+}
+
+// LeaderElection lets several replicas run while only the holder of the
+// Lease ResourceNamespace/ResourceName schedules pods.
+type LeaderElection struct {
+	LeaderElect *bool `json:"leaderElect,omitempty"`
+	// LeaseDuration is how long a standby waits before taking over a lease
+	// that is not renewed.
+	LeaseDuration metav1.Duration `json:"leaseDuration"`
+	// RenewDeadline is how long the leader retries renewing before it gives up
+	// leadership. Must be less than LeaseDuration.
+	RenewDeadline metav1.Duration `json:"renewDeadline"`
+	// RetryPeriod is the interval between tries to acquire or renew the lease.
+	RetryPeriod       metav1.Duration `json:"retryPeriod"`
+	ResourceName      string          `json:"resourceName"`
+	ResourceNamespace string          `json:"resourceNamespace"`
+}
+
+// Profile schedules the pods whose spec.schedulerName is SchedulerName.
+// Control plane nodes are kept out only by their taint, so a profile whose
+// Predicates leave out TaintToleration may schedule pods to them.
+type Profile struct {
+	SchedulerName string           `json:"schedulerName"`
+	Predicates    []string         `json:"predicates"`
+	Priorities    []PriorityWeight `json:"priorities"`
+	TieBreak      string           `json:"tieBreak"`
+	Metrics       MetricsSource    `json:"metrics"`
+}
+
+type PriorityWeight struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+}
+
+type MetricsSource struct {
+	Provider   string            `json:"provider"`
+	InfluxDB   *InfluxDBSource   `json:"influxdb,omitempty"`
+	Prometheus *PrometheusSource `json:"prometheus,omitempty"`
+	Static     *StaticSource     `json:"static,omitempty"`
+}
+
+type InfluxDBSource struct {
+	URL      string `json:"url"`
+	Database string `json:"database"`
+}
+
+type PrometheusSource struct {
+	URL string `json:"url"`
+	// NodeLabel is the DCGM exporter label holding the node name.
+	NodeLabel string `json:"nodeLabel"`
+}
+
+type StaticSource struct {
+	// File is a JSON or YAML file of node and GPU metrics.
+	File string `json:"file"`
+}
+
+// DefaultConfiguration is used when no configuration file is given.
+func DefaultConfiguration() *SchedulerConfiguration {
+	cfg := &SchedulerConfiguration{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Profiles: []Profile{
+			{SchedulerName: SchedulerName},
+		},
+	}
+	SetDefaults(cfg)
+	return cfg
+}
+
+// SetDefaults fills the fields left empty in the configuration file.
+func SetDefaults(cfg *SchedulerConfiguration) {
+	if cfg.ReconcileInterval.Duration == 0 {
+		cfg.ReconcileInterval.Duration = 30 * time.Second
+	}
+	if cfg.PodInitialBackoff.Duration == 0 {
+		cfg.PodInitialBackoff.Duration = 1 * time.Second
+	}
+	if cfg.PodMaxBackoff.Duration == 0 {
+		cfg.PodMaxBackoff.Duration = 10 * time.Second
+	}
+	if cfg.MPSClientsPerGPU == 0 {
+		cfg.MPSClientsPerGPU = 4
+	}
+	if cfg.Parallelism == 0 {
+		cfg.Parallelism = 16
+	}
+	if cfg.MetricsBindAddress == "" {
+		cfg.MetricsBindAddress = ":10251"
+	}
+	setLeaderElectionDefaults(&cfg.LeaderElection)
+	for i := range cfg.Profiles {
+		profile := &cfg.Profiles[i]
+		if profile.Predicates == nil {
+			profile.Predicates = []string{"NodeAffinity", "TaintToleration", "InterPodAffinity", "PodFitsResources", "PodFitsGPU"}
+		}
+		if profile.Priorities == nil {
+			profile.Priorities = []PriorityWeight{{Name: "MetricBasedScoring", Weight: 1}, {Name: "NodeAffinity", Weight: 1}, {Name: "InterPodAffinity", Weight: 1}}
+		}
+		if profile.TieBreak == "" {
+			profile.TieBreak = RandomTieBreak
+		}
+		if profile.Metrics.Provider == "" {
+			profile.Metrics.Provider = InfluxDBMetricsProvider
+		}
+		if profile.Metrics.Provider == InfluxDBMetricsProvider && profile.Metrics.InfluxDB == nil {
+			profile.Metrics.InfluxDB = &InfluxDBSource{}
+		}
+		if influxdb := profile.Metrics.InfluxDB; influxdb != nil {
+			if influxdb.URL == "" {
+				influxdb.URL = "http://influxdb.gpu.svc.cluster.local:8086"
+			}
+			if influxdb.Database == "" {
+				influxdb.Database = "multimetric"
+			}
+		}
+		if prometheus := profile.Metrics.Prometheus; prometheus != nil && prometheus.NodeLabel == "" {
+			prometheus.NodeLabel = "Hostname"
+		}
+	}
+}
+
+func setLeaderElectionDefaults(le *LeaderElection) {
+	if le.LeaderElect == nil {
+		leaderElect := true
+		le.LeaderElect = &leaderElect
+	}
+	if le.LeaseDuration.Duration == 0 {
+		le.LeaseDuration.Duration = 15 * time.Second
+	}
+	if le.RenewDeadline.Duration == 0 {
+		le.RenewDeadline.Duration = 10 * time.Second
+	}
+	if le.RetryPeriod.Duration == 0 {
+		le.RetryPeriod.Duration = 2 * time.Second
+	}
+	if le.ResourceName == "" {
+		le.ResourceName = SchedulerName
+	}
+	if le.ResourceNamespace == "" {
+		le.ResourceNamespace = "gpu"
+	}
+}
+
+// LoadConfiguration reads, defaults and validates the configuration file.
+func LoadConfiguration(path string) (*SchedulerConfiguration, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	cfg := &SchedulerConfiguration{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	SetDefaults(cfg)
+	if err := Validate(cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks the configuration and returns every problem found.
+// Plugin names are checked when the profiles are built.
+func Validate(cfg *SchedulerConfiguration) error {
+	allErrs := field.ErrorList{}
+
+	if cfg.APIVersion != APIVersion {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("apiVersion"), cfg.APIVersion, []string{APIVersion}))
+	}
+	if cfg.Kind != Kind {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("kind"), cfg.Kind, []string{Kind}))
+	}
+	if cfg.ReconcileInterval.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("reconcileInterval"), cfg.ReconcileInterval.Duration.String(), "must be greater than 0"))
+	}
+	if cfg.PodInitialBackoff.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("podInitialBackoff"), cfg.PodInitialBackoff.Duration.String(), "must be greater than 0"))
+	}
+	if cfg.PodMaxBackoff.Duration < cfg.PodInitialBackoff.Duration {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("podMaxBackoff"), cfg.PodMaxBackoff.Duration.String(), "must not be less than podInitialBackoff"))
+	}
+	if cfg.MPSClientsPerGPU <= 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("mpsClientsPerGPU"), cfg.MPSClientsPerGPU, "must be greater than 0"))
+	}
+	if cfg.Parallelism <= 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("parallelism"), cfg.Parallelism, "must be greater than 0"))
+	}
+	if cfg.PercentageOfNodesToScore < 0 || cfg.PercentageOfNodesToScore > 100 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("percentageOfNodesToScore"), cfg.PercentageOfNodesToScore, "must be in the range [0, 100]"))
+	}
+
+	if _, _, err := net.SplitHostPort(cfg.MetricsBindAddress); err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("metricsBindAddress"), cfg.MetricsBindAddress, err.Error()))
+	}
+	allErrs = append(allErrs, validateLeaderElection(cfg.LeaderElection, field.NewPath("leaderElection"))...)
+
+	profilesPath := field.NewPath("profiles")
+	if len(cfg.Profiles) == 0 {
+		allErrs = append(allErrs, field.Required(profilesPath, "at least one profile is needed"))
+	}
+	schedulerNames := make(map[string]bool)
+	for i, profile := range cfg.Profiles {
+		path := profilesPath.Index(i)
+		if profile.SchedulerName == "" {
+			allErrs = append(allErrs, field.Required(path.Child("schedulerName"), ""))
+		} else if schedulerNames[profile.SchedulerName] {
+			allErrs = append(allErrs, field.Duplicate(path.Child("schedulerName"), profile.SchedulerName))
+		}
+		schedulerNames[profile.SchedulerName] = true
+
+		if len(profile.Priorities) == 0 {
+			allErrs = append(allErrs, field.Required(path.Child("priorities"), "at least one priority is needed"))
+		}
+		for j, priority := range profile.Priorities {
+			if priority.Name == "" {
+				allErrs = append(allErrs, field.Required(path.Child("priorities").Index(j).Child("name"), ""))
+			}
+			if priority.Weight < 0 {
+				allErrs = append(allErrs, field.Invalid(path.Child("priorities").Index(j).Child("weight"), priority.Weight, "must not be negative"))
+			}
+		}
+
+		if profile.TieBreak != RandomTieBreak && profile.TieBreak != RoundRobinTieBreak {
+			allErrs = append(allErrs, field.NotSupported(path.Child("tieBreak"), profile.TieBreak, []string{RandomTieBreak, RoundRobinTieBreak}))
+		}
+		allErrs = append(allErrs, validateMetricsSource(profile.Metrics, path.Child("metrics"))...)
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return allErrs.ToAggregate()
+}
+
+func validateMetricsSource(source MetricsSource, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	switch source.Provider {
+	case InfluxDBMetricsProvider:
+		if source.InfluxDB == nil || source.InfluxDB.URL == "" {
+			allErrs = append(allErrs, field.Required(path.Child("influxdb", "url"), ""))
+		}
+	case PrometheusMetricsProvider:
+		if source.Prometheus == nil || source.Prometheus.URL == "" {
+			allErrs = append(allErrs, field.Required(path.Child("prometheus", "url"), ""))
+		}
+	case StaticMetricsProvider:
+		if source.Static == nil || source.Static.File == "" {
+			allErrs = append(allErrs, field.Required(path.Child("static", "file"), ""))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(path.Child("provider"), source.Provider,
+			[]string{InfluxDBMetricsProvider, PrometheusMetricsProvider, StaticMetricsProvider}))
+	}
+	return allErrs
+}
+
+func validateLeaderElection(le LeaderElection, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if le.LeaderElect == nil || !*le.LeaderElect {
+		return allErrs
+	}
+	if le.LeaseDuration.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("leaseDuration"), le.LeaseDuration.Duration.String(), "must be greater than 0"))
+	}
+	if le.RenewDeadline.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("renewDeadline"), le.RenewDeadline.Duration.String(), "must be greater than 0"))
+	} else if le.RenewDeadline.Duration >= le.LeaseDuration.Duration {
+		allErrs = append(allErrs, field.Invalid(path.Child("renewDeadline"), le.RenewDeadline.Duration.String(), "must be less than leaseDuration"))
+	}
+	if le.RetryPeriod.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("retryPeriod"), le.RetryPeriod.Duration.String(), "must be greater than 0"))
+	} else if float64(le.RenewDeadline.Duration) <= 1.2*float64(le.RetryPeriod.Duration) {
+		//client-go의 leaderelection.JitterFactor(1.2)
+		allErrs = append(allErrs, field.Invalid(path.Child("retryPeriod"), le.RetryPeriod.Duration.String(), "must be less than renewDeadline/1.2"))
+	}
+	if le.ResourceName == "" {
+		allErrs = append(allErrs, field.Required(path.Child("resourceName"), ""))
+	}
+	if le.ResourceNamespace == "" {
+		allErrs = append(allErrs, field.Required(path.Child("resourceNamespace"), ""))
+	}
+	return allErrs
+}
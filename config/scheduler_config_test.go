@@ -0,0 +1,175 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *SchedulerConfiguration)
+		wantErr string //field path in the error, empty if valid
+	}{
+		{
+			name:   "default configuration",
+			modify: func(cfg *SchedulerConfiguration) {},
+		},
+		{
+			name:    "wrong apiVersion",
+			modify:  func(cfg *SchedulerConfiguration) { cfg.APIVersion = "v1" },
+			wantErr: "apiVersion",
+		},
+		{
+			name:    "zero reconcileInterval",
+			modify:  func(cfg *SchedulerConfiguration) { cfg.ReconcileInterval.Duration = 0 },
+			wantErr: "reconcileInterval",
+		},
+		{
+			name: "podMaxBackoff less than podInitialBackoff",
+			modify: func(cfg *SchedulerConfiguration) {
+				cfg.PodMaxBackoff.Duration = cfg.PodInitialBackoff.Duration / 2
+			},
+			wantErr: "podMaxBackoff",
+		},
+		{
+			name:    "zero parallelism",
+			modify:  func(cfg *SchedulerConfiguration) { cfg.Parallelism = 0 },
+			wantErr: "parallelism",
+		},
+		{
+			name:    "percentageOfNodesToScore over 100",
+			modify:  func(cfg *SchedulerConfiguration) { cfg.PercentageOfNodesToScore = 101 },
+			wantErr: "percentageOfNodesToScore",
+		},
+		{
+			name:    "metricsBindAddress without port",
+			modify:  func(cfg *SchedulerConfiguration) { cfg.MetricsBindAddress = "0.0.0.0" },
+			wantErr: "metricsBindAddress",
+		},
+		{
+			name: "renewDeadline not less than leaseDuration",
+			modify: func(cfg *SchedulerConfiguration) {
+				cfg.LeaderElection.RenewDeadline = cfg.LeaderElection.LeaseDuration
+			},
+			wantErr: "leaderElection.renewDeadline",
+		},
+		{
+			name: "leader election disabled skips its checks",
+			modify: func(cfg *SchedulerConfiguration) {
+				leaderElect := false
+				cfg.LeaderElection.LeaderElect = &leaderElect
+				cfg.LeaderElection.RenewDeadline = cfg.LeaderElection.LeaseDuration
+			},
+		},
+		{
+			name:    "no profile",
+			modify:  func(cfg *SchedulerConfiguration) { cfg.Profiles = nil },
+			wantErr: "profiles",
+		},
+		{
+			name: "duplicate schedulerName",
+			modify: func(cfg *SchedulerConfiguration) {
+				cfg.Profiles = append(cfg.Profiles, cfg.Profiles[0])
+			},
+			wantErr: "profiles[1].schedulerName",
+		},
+		{
+			name: "negative priority weight",
+			modify: func(cfg *SchedulerConfiguration) {
+				cfg.Profiles[0].Priorities = []PriorityWeight{{Name: "MostAllocated", Weight: -1}}
+			},
+			wantErr: "profiles[0].priorities[0].weight",
+		},
+		{
+			name:    "unknown tieBreak",
+			modify:  func(cfg *SchedulerConfiguration) { cfg.Profiles[0].TieBreak = "first" },
+			wantErr: "profiles[0].tieBreak",
+		},
+		{
+			name: "prometheus without url",
+			modify: func(cfg *SchedulerConfiguration) {
+				cfg.Profiles[0].Metrics = MetricsSource{Provider: PrometheusMetricsProvider}
+			},
+			wantErr: "profiles[0].metrics.prometheus.url",
+		},
+		{
+			name: "unknown metrics provider",
+			modify: func(cfg *SchedulerConfiguration) {
+				cfg.Profiles[0].Metrics.Provider = "graphite"
+			},
+			wantErr: "profiles[0].metrics.provider",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfiguration()
+			tt.modify(cfg)
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want an error on %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestDeploymentConfiguration checks that the configuration in the
+// deployments is valid and has a profile for every schedulerName used by
+// the example pods.
+func TestDeploymentConfiguration(t *testing.T) {
+	data, err := ioutil.ReadFile("../deployments/scheduler-config.yaml")
+	if err != nil {
+		t.Fatalf("failed to read ConfigMap: %v", err)
+	}
+	configMap := struct {
+		Data map[string]string `json:"data"`
+	}{}
+	if err := yaml.Unmarshal(data, &configMap); err != nil {
+		t.Fatalf("failed to parse ConfigMap: %v", err)
+	}
+
+	cfg := &SchedulerConfiguration{}
+	if err := yaml.UnmarshalStrict([]byte(configMap.Data["scheduler-config.yaml"]), cfg); err != nil {
+		t.Fatalf("failed to parse configuration: %v", err)
+	}
+	SetDefaults(cfg)
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	schedulerNames := make(map[string]bool)
+	for _, profile := range cfg.Profiles {
+		schedulerNames[profile.SchedulerName] = true
+	}
+
+	manifests, err := filepath.Glob("../deployments/*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedulerNameRe := regexp.MustCompile(`(?m)^\s*schedulerName:\s*(\S+)`)
+	for _, manifest := range manifests {
+		if filepath.Base(manifest) == "scheduler-config.yaml" {
+			continue
+		}
+		data, err := ioutil.ReadFile(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range schedulerNameRe.FindAllStringSubmatch(string(data), -1) {
+			if !schedulerNames[match[1]] {
+				t.Errorf("%s uses schedulerName %s, which has no profile", filepath.Base(manifest), match[1])
+			}
+		}
+	}
+}
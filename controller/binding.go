@@ -17,73 +17,193 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"strings"
+	"time"
 
+	"gpu-scheduler/logging"
 	"gpu-scheduler/postevent"
+	resource "gpu-scheduler/resourceinfo"
+	framework "gpu-scheduler/vlalpha1"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
 )
 
-var isTrue bool = true
+const (
+	apiCallMaxTries       = 5
+	apiCallInitialBackoff = 100 * time.Millisecond
+	apiCallMaxBackoff     = 2 * time.Second
+)
 
 //write GPUID to annotation
 func PatchPodAnnotationSpec(devId string) ([]byte, error) {
 	patchAnnotations := map[string]interface{}{
 		"metadata": map[string]map[string]string{"annotations": {
-			"UUID": devId,
+			resource.UUIDAnnotation: devId,
 		}}}
 
 	return json.Marshal(patchAnnotations)
 }
 
-func PatchPodAnnotation(pod *corev1.Pod, devId string) error {
-	host_config, _ := rest.InClusterConfig()
-	host_kubeClient := kubernetes.NewForConfigOrDie(host_config)
-
+func PatchPodAnnotation(ctx context.Context, pod *corev1.Pod, devId string) error {
 	patchedAnnotationBytes, err := PatchPodAnnotationSpec(devId)
 	if err != nil {
 		return fmt.Errorf("failed to generate patched annotations,reason: %v", err)
 	}
 
-	pod, err = host_kubeClient.CoreV1().Pods(pod.Namespace).Patch(context.TODO(), pod.Name, types.StrategicMergePatchType, patchedAnnotationBytes, metav1.PatchOptions{})
+	pod, err = KubeClient.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.StrategicMergePatchType, patchedAnnotationBytes, metav1.PatchOptions{})
 	if err != nil {
-		fmt.Println("patchPodAnnotation error: ", err)
 		return err
 	}
 	return nil
 }
 
-func Binding(pod *corev1.Pod, bestNode corev1.Node) error {
-	var devId string
-
-	fmt.Println("3. Binding stage")
-
-	//임의로 지정된 deviceID
-	for _, container := range pod.Spec.Containers { //파드의 컨테이너 검사
-		GPUreq := container.Resources.Limits["keti.com/mpsgpu"]
-		if GPUreq.String() == "2" {
-			devId = "GPU-a06cd524-72c4-d6f0-4eda-d64af512dd8b,GPU-f6db4146-092d-146f-0814-8ff90b04f3d2"
-		} else {
-			if isTrue {
-				devId = "GPU-a06cd524-72c4-d6f0-4eda-d64af512dd8b"
-				isTrue = false
-			} else {
-				devId = "GPU-f6db4146-092d-146f-0814-8ff90b04f3d2"
-				isTrue = true
-			}
+// RemovePodAnnotation removes the GPU UUIDs written by PatchPodAnnotation
+// from a pod whose binding failed.
+func RemovePodAnnotation(ctx context.Context, pod *corev1.Pod) error {
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:null}}}`, resource.UUIDAnnotation))
+	_, err := KubeClient.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// Assume reserves the node for the pod through the Reserve plugins and adds
+// the pod to the cache as bound, so the next pod is scheduled against the
+// reserved capacity without waiting for the binding.
+func Assume(ctx context.Context, fwk *framework.Framework, state *framework.CycleState, pod *corev1.Pod, nodeName string) error {
+	status := fwk.RunReservePlugins(ctx, state, pod, nodeName)
+	if !status.IsSuccess() {
+		return status.AsError()
+	}
+	if err := resource.Cache.AssumePod(pod, nodeName); err != nil {
+		fwk.RunUnreservePlugins(ctx, state, pod, nodeName)
+		return err
+	}
+	return nil
+}
+
+// Binding binds the assumed pod through the Permit, PreBind, Bind and
+// PostBind plugins. If any of them fails, the reservation is rolled back and
+// the pod is removed from the cache.
+func Binding(ctx context.Context, fwk *framework.Framework, state *framework.CycleState, pod *corev1.Pod, bestNode corev1.Node) error {
+	status := fwk.RunPermitPlugins(ctx, state, pod, bestNode.Name)
+	if status.IsSuccess() {
+		status = fwk.RunPreBindPlugins(ctx, state, pod, bestNode.Name)
+	}
+	if status.IsSuccess() {
+		status = fwk.RunBindPlugins(ctx, state, pod, bestNode.Name)
+	}
+	if !status.IsSuccess() {
+		logging.FromContext(ctx).V(2).Info("Binding failed, unreserving node", "node", bestNode.Name, "reason", status.Message())
+		fwk.RunUnreservePlugins(ctx, state, pod, bestNode.Name)
+		resource.Cache.ForgetPod(pod)
+		return status.AsError()
+	}
+
+	resource.Cache.FinishBinding(pod)
+	fwk.RunPostBindPlugins(ctx, state, pod, bestNode.Name)
+	return nil
+}
+
+// retryAPICall calls fn until it succeeds, fails with an error that
+// retrying does not fix, or apiCallMaxTries is reached.
+func retryAPICall(ctx context.Context, fn func() error) error {
+	backoff := apiCallInitialBackoff
+	for try := 1; ; try++ {
+		err := fn()
+		if err == nil || !isRetriable(err) || try == apiCallMaxTries {
+			return err
+		}
+		logging.FromContext(ctx).V(2).Info("Retrying API call", "try", try, "backoff", backoff, "err", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+		if backoff > apiCallMaxBackoff {
+			backoff = apiCallMaxBackoff
 		}
 	}
+}
 
-	//파드 스펙에 GPU 업데이트
-	err := PatchPodAnnotation(pod, devId)
+func isRetriable(err error) bool {
+	return apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) || apierrors.IsTooManyRequests(err) ||
+		apierrors.IsInternalError(err) || apierrors.IsServiceUnavailable(err) ||
+		utilnet.IsConnectionReset(err) || utilnet.IsProbableEOF(err)
+}
+
+const (
+	GPUDeviceAllocationName = "GPUDeviceAllocation"
+	DefaultBinderName       = "DefaultBinder"
+)
+
+// GPUDeviceAllocation reserves concrete GPU devices of the node for the pod
+// and writes their UUIDs into the pod annotation before binding.
+type GPUDeviceAllocation struct{}
+
+var _ framework.ReservePlugin = &GPUDeviceAllocation{}
+var _ framework.PreBindPlugin = &GPUDeviceAllocation{}
+
+func (pl *GPUDeviceAllocation) Name() string {
+	return GPUDeviceAllocationName
+}
+
+// 선택된 노드에서 요청 개수만큼 비어있는 GPU 할당
+func (pl *GPUDeviceAllocation) Reserve(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodeName string) *framework.Status {
+	gpuReq := resource.GPURequest(pod)
+	if gpuReq == 0 {
+		return nil
+	}
+	if _, err := resource.Allocator.Allocate(pod, nodeName, int(gpuReq)); err != nil {
+		return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("failed to allocate GPU on node %s,reason: %v", nodeName, err))
+	}
+	return nil
+}
+
+func (pl *GPUDeviceAllocation) Unreserve(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodeName string) {
+	if _, ok := resource.Ledger.Assignment(pod.UID); !ok {
+		return
+	}
+	resource.Ledger.Release(pod)
+	//PreBind에서 기록한 UUID가 남아있으면 다음 스케줄링 전에 제거
+	if err := retryAPICall(ctx, func() error { return RemovePodAnnotation(ctx, pod) }); err != nil {
+		logging.FromContext(ctx).Error(err, "Failed to remove stale GPU annotation", "node", nodeName)
+	}
+}
+
+// 파드 스펙에 GPU 업데이트
+func (pl *GPUDeviceAllocation) PreBind(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodeName string) *framework.Status {
+	assignment, ok := resource.Ledger.Assignment(pod.UID)
+	if !ok {
+		return nil
+	}
+	err := retryAPICall(ctx, func() error {
+		return PatchPodAnnotation(ctx, pod, strings.Join(assignment.UUIDs, ","))
+	})
 	if err != nil {
-		return fmt.Errorf("failed to generate patched annotations,reason: %v", err)
+		return framework.AsStatus(fmt.Errorf("failed to generate patched annotations,reason: %v", err))
 	}
+	return nil
+}
 
+// DefaultBinder binds the pod through the API server and emits the
+// Scheduled event.
+type DefaultBinder struct{}
+
+var _ framework.BindPlugin = &DefaultBinder{}
+var _ framework.PostBindPlugin = &DefaultBinder{}
+
+func (pl *DefaultBinder) Name() string {
+	return DefaultBinderName
+}
+
+func (pl *DefaultBinder) Bind(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodeName string) *framework.Status {
 	binding := &corev1.Binding{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: pod.Name,
@@ -95,28 +215,34 @@ func Binding(pod *corev1.Pod, bestNode corev1.Node) error {
 		Target: corev1.ObjectReference{
 			APIVersion: "v1",
 			Kind:       "Node",
-			Name:       bestNode.Name,
+			Name:       nodeName,
 		},
 	}
 
-	host_config, _ := rest.InClusterConfig()
-	host_kubeClient := kubernetes.NewForConfigOrDie(host_config)
-
-	err = host_kubeClient.CoreV1().Pods(pod.Namespace).Bind(context.TODO(), binding, metav1.CreateOptions{})
-	if err != nil {
-		fmt.Println("binding error: ", err)
+	err := retryAPICall(ctx, func() error {
+		err := KubeClient.CoreV1().Pods(pod.Namespace).Bind(ctx, binding, metav1.CreateOptions{})
+		if apierrors.IsConflict(err) {
+			//응답을 받지 못한 이전 시도가 이미 바인딩했을 수 있음
+			current, getErr := KubeClient.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+			if getErr == nil && current.Spec.NodeName == nodeName {
+				return nil
+			}
+		}
 		return err
+	})
+	if err != nil {
+		return framework.AsStatus(err)
 	}
+	return nil
+}
 
-	// Emit a Kubernetes event that the Pod was scheduled successfully.
-	message := fmt.Sprintf("Successfully assigned %s to %s and GPU UUID is %s", pod.ObjectMeta.Name, bestNode.ObjectMeta.Name, devId)
-	event := postevent.MakeBindEvent(pod, message)
-	log.Println(message)
-	err = postevent.PostEvent(event)
-	if nil != err {
-		fmt.Println("binding>postEvent error: ", err)
-		return err
+// Emit a Kubernetes event that the Pod was scheduled successfully.
+func (pl *DefaultBinder) PostBind(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodeName string) {
+	postevent.ScheduledEvent(pod, nodeName)
+	if assignment, ok := resource.Ledger.Assignment(pod.UID); ok {
+		postevent.GPUAssignedEvent(pod, nodeName, assignment.UUIDs)
+		logging.FromContext(ctx).V(2).Info("Bound pod", "node", nodeName, "gpus", strings.Join(assignment.UUIDs, ","))
+		return
 	}
-
-	return nil
+	logging.FromContext(ctx).V(2).Info("Bound pod", "node", nodeName)
 }
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Loops checked by /healthz.
+const (
+	watchLoop     = "watch"
+	reconcileLoop = "reconcile"
+
+	//watch 루프는 이벤트가 없어도 heartbeatInterval마다 갱신
+	heartbeatInterval = 10 * time.Second
+	watchLoopTimeout  = 2 * time.Minute
+)
+
+// heartbeats keeps the last time every running loop made progress. A loop
+// that did not beat within its timeout is stalled.
+var heartbeats = &loopHeartbeats{
+	last:     make(map[string]time.Time),
+	timeouts: make(map[string]time.Duration),
+}
+
+type loopHeartbeats struct {
+	mu       sync.Mutex
+	last     map[string]time.Time
+	timeouts map[string]time.Duration
+}
+
+func (h *loopHeartbeats) start(loop string, timeout time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last[loop] = time.Now()
+	h.timeouts[loop] = timeout
+}
+
+func (h *loopHeartbeats) beat(loop string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.timeouts[loop]; ok {
+		h.last[loop] = time.Now()
+	}
+}
+
+// stop removes the loop, e.g. when this replica is not the leader anymore.
+func (h *loopHeartbeats) stop(loop string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.last, loop)
+	delete(h.timeouts, loop)
+}
+
+func (h *loopHeartbeats) check() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stalled := make([]string, 0)
+	now := time.Now()
+	for loop, timeout := range h.timeouts {
+		if since := now.Sub(h.last[loop]); since > timeout {
+			stalled = append(stalled, fmt.Sprintf("%s loop stalled for %s", loop, since.Round(time.Second)))
+		}
+	}
+	if len(stalled) == 0 {
+		return nil
+	}
+	sort.Strings(stalled)
+	return fmt.Errorf("%s", strings.Join(stalled, ", "))
+}
+
+// Healthz fails if the watch or reconcile loop of the leader stalled.
+func Healthz() error {
+	return heartbeats.check()
+}
+
+// leadership state, unknown until the first leader is observed
+var (
+	leaderMu    sync.RWMutex
+	leaderKnown bool
+	isLeader    bool
+)
+
+func setLeadership(leader bool) {
+	leaderMu.Lock()
+	defer leaderMu.Unlock()
+	leaderKnown = true
+	isLeader = leader
+}
+
+// Leadership reports whether this replica knows who the leader is and
+// whether it is the leader.
+func Leadership() (known bool, leader bool) {
+	leaderMu.RLock()
+	defer leaderMu.RUnlock()
+	return leaderKnown, isLeader
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"gpu-scheduler/logging"
+	resource "gpu-scheduler/resourceinfo"
+)
+
+// CacheDump is the response of /debug/cache.
+type CacheDump struct {
+	Nodes          []resource.NodeDump      `json:"nodes"`
+	GPUAssignments []resource.GPUAssignment `json:"gpuAssignments"`
+}
+
+// InstallHandlers adds /healthz, /readyz, /debug/cache and /debug/queue.
+func InstallHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if err := Healthz(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if resource.Cache == nil || !resource.Cache.HasSynced() {
+			http.Error(w, "caches are not synced", http.StatusServiceUnavailable)
+			return
+		}
+		if known, _ := Leadership(); !known {
+			http.Error(w, "leadership is not known", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	})
+
+	mux.HandleFunc("/debug/cache", func(w http.ResponseWriter, r *http.Request) {
+		if resource.Cache == nil {
+			http.Error(w, "cache is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, CacheDump{
+			Nodes:          resource.Cache.Dump(),
+			GPUAssignments: resource.Ledger.Assignments(),
+		})
+	})
+
+	mux.HandleFunc("/debug/queue", func(w http.ResponseWriter, r *http.Request) {
+		if schedulingQueue == nil {
+			http.Error(w, "scheduling queue is not initialized", http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, schedulingQueue.Dump())
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		logging.Error(err, "Failed to write debug response")
+	}
+}
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"gpu-scheduler/config"
+	"gpu-scheduler/logging"
+	resource "gpu-scheduler/resourceinfo"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// Run starts the scheduling queue, the watch and reconcile loops that fill
+// it and the loop that schedules from it, and blocks until ctx is done.
+func Run(ctx context.Context, cfg *config.SchedulerConfiguration) {
+	var wg sync.WaitGroup //모든 고루틴이 종료될 때 까지 대기할 때 사용
+
+	setLeadership(true)
+
+	//노드 추가/변경, 파드 종료 시 unschedulable 파드 재시도
+	resource.Cache.AddClusterEventHandler(schedulingQueue.MoveAllToActiveOrBackoffQueue)
+
+	wg.Add(1) //대기 중인 고루틴 개수 추가
+	go func() {
+		defer wg.Done()
+		schedulingQueue.Run(ctx.Done()) //백오프가 끝난 파드를 activeQ로 이동
+	}()
+
+	wg.Add(1)
+	go MonitorUnscheduledPods(ctx.Done(), &wg) //새로 들어온 파드 감시 루틴
+
+	wg.Add(1)
+	go ReconcileUnscheduledPods(cfg.ReconcileInterval.Duration, ctx.Done(), &wg) //watch에서 놓친 파드 reconcileInterval 간격 큐에 추가
+
+	wg.Add(1)
+	go ScheduleQueuedPods(&wg) //큐에서 꺼낸 파드 스케줄링
+
+	wg.Wait()
+}
+
+// RunWithLeaderElection runs the scheduler only while this replica holds the
+// lease. Standby replicas keep their caches synced and take over when the
+// lease expires. A leader that loses the lease exits at once so that two
+// replicas never schedule at the same time. When ctx is done it returns
+// after Run finished and the lease was released.
+func RunWithLeaderElection(ctx context.Context, cfg *config.SchedulerConfiguration) error {
+	le := cfg.LeaderElection
+	hostname, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("failed to get hostname,reason: %v", err)
+	}
+	//같은 파드에서 재시작해도 이전 프로세스와 구분되도록 시작 시각을 붙임
+	identity := hostname + "_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      le.ResourceName,
+			Namespace: le.ResourceNamespace,
+		},
+		Client: KubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+
+	//lease는 스케줄링 고루틴이 모두 종료된 뒤 반납하도록 elector는 별도 context로 실행
+	electorCtx, stopElector := context.WithCancel(context.Background())
+	defer stopElector()
+	var (
+		mu      sync.Mutex
+		leading bool
+		runDone = make(chan struct{})
+	)
+	go func() {
+		<-ctx.Done()
+		mu.Lock()
+		wasLeading := leading
+		mu.Unlock()
+		if wasLeading {
+			<-runDone
+		}
+		stopElector()
+	}()
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: le.LeaseDuration.Duration,
+		RenewDeadline: le.RenewDeadline.Duration,
+		RetryPeriod:   le.RetryPeriod.Duration,
+		//종료 시 lease를 반납해 대기 중인 레플리카가 바로 넘겨받음
+		ReleaseOnCancel: true,
+		Name:            le.ResourceName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			//client-go가 별도 고루틴에서 호출하므로 종료 시 runDone으로 Run이 끝났음을 알림
+			OnStartedLeading: func(context.Context) {
+				mu.Lock()
+				//종료 중에 lease를 얻은 경우 스케줄링하지 않음
+				if ctx.Err() != nil {
+					mu.Unlock()
+					return
+				}
+				leading = true
+				mu.Unlock()
+				defer close(runDone)
+
+				setLeadership(true)
+				logging.Info("Became leader, starting scheduling", "identity", identity)
+				Run(ctx, cfg)
+			},
+			OnStoppedLeading: func() {
+				select {
+				case <-ctx.Done():
+					logging.Info("Released lease", "lease", le.ResourceNamespace+"/"+le.ResourceName)
+				default:
+					logging.Error(nil, "Lost lease, stopping scheduling", "lease", le.ResourceNamespace+"/"+le.ResourceName)
+					os.Exit(1)
+				}
+			},
+			OnNewLeader: func(leader string) {
+				setLeadership(leader == identity)
+				if leader != identity {
+					logging.Info("New leader elected", "leader", leader)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create leader elector,reason: %v", err)
+	}
+
+	logging.Info("Waiting for lease", "lease", le.ResourceNamespace+"/"+le.ResourceName, "identity", identity)
+	elector.Run(electorCtx)
+	return nil
+}
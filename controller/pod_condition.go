@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"gpu-scheduler/algorithm/predicates"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Reason of the PodScheduled condition when scheduling failed for another
+// reason than no node fitting the pod.
+const SchedulerError = "SchedulerError"
+
+// updateUnschedulableCondition sets PodScheduled=False on the pod so that
+// kubectl describe shows why it is pending. The status is updated only when
+// the reason or message changed.
+func updateUnschedulableCondition(ctx context.Context, pod *corev1.Pod, err error) error {
+	reason := SchedulerError
+	if _, ok := err.(*predicates.FitError); ok {
+		reason = corev1.PodReasonUnschedulable
+	}
+	condition := corev1.PodCondition{
+		Type:    corev1.PodScheduled,
+		Status:  corev1.ConditionFalse,
+		Reason:  reason,
+		Message: err.Error(),
+	}
+
+	podCopy := pod.DeepCopy()
+	if !setPodCondition(&podCopy.Status, condition) {
+		return nil
+	}
+	_, err = KubeClient.CoreV1().Pods(pod.Namespace).UpdateStatus(ctx, podCopy, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		//바인딩 실패 전에 어노테이션이 패치된 경우, 최신 파드로 한 번 더 시도
+		podCopy, err = KubeClient.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if err == nil && setPodCondition(&podCopy.Status, condition) {
+			_, err = KubeClient.CoreV1().Pods(pod.Namespace).UpdateStatus(ctx, podCopy, metav1.UpdateOptions{})
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update PodScheduled condition,reason: %v", err)
+	}
+	return nil
+}
+
+// setPodCondition returns false if the same condition is already set.
+func setPodCondition(status *corev1.PodStatus, condition corev1.PodCondition) bool {
+	now := metav1.Now()
+	for i := range status.Conditions {
+		old := &status.Conditions[i]
+		if old.Type != condition.Type {
+			continue
+		}
+		if old.Status == condition.Status && old.Reason == condition.Reason && old.Message == condition.Message {
+			return false
+		}
+		if old.Status != condition.Status {
+			old.LastTransitionTime = now
+		}
+		old.Status = condition.Status
+		old.Reason = condition.Reason
+		old.Message = condition.Message
+		old.LastProbeTime = now
+		return true
+	}
+
+	condition.LastProbeTime = now
+	condition.LastTransitionTime = now
+	status.Conditions = append(status.Conditions, condition)
+	return true
+}
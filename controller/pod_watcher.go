@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"gpu-scheduler/logging"
+	resource "gpu-scheduler/resourceinfo"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// 바인딩되지 않고 종료되지 않은 파드
+	unscheduledPodSelector = "spec.nodeName=,status.phase!=Succeeded,status.phase!=Failed"
+
+	watchInitialBackoff = 1 * time.Second
+	watchMaxBackoff     = 30 * time.Second
+)
+
+// PodEvent is an added, modified or deleted unscheduled pod. A pod that is
+// bound leaves the watched set and is delivered as deleted.
+type PodEvent struct {
+	Type watch.EventType
+	Pod  *corev1.Pod
+}
+
+// podWatcher lists and watches the unscheduled pods. It resumes the watch
+// from the last resourceVersion it saw, relists when that version is too
+// old (410 Gone), and backs off while the API server returns errors.
+type podWatcher struct {
+	events chan PodEvent
+	errc   chan error
+
+	resourceVersion string
+	// 마지막 list/watch 기준으로 알고 있는 파드, relist 시 사라진 파드를 삭제 이벤트로 전달
+	known   map[string]*corev1.Pod
+	backoff time.Duration
+}
+
+func WatchUnscheduledPods(done <-chan struct{}) (<-chan PodEvent, <-chan error) {
+	w := &podWatcher{
+		events:  make(chan PodEvent),
+		errc:    make(chan error, 1),
+		known:   make(map[string]*corev1.Pod),
+		backoff: watchInitialBackoff,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-done
+		cancel()
+	}()
+	go w.run(ctx)
+	return w.events, w.errc
+}
+
+func (w *podWatcher) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		if w.resourceVersion == "" {
+			if err := w.relist(ctx); err != nil {
+				w.fail(ctx, fmt.Errorf("failed to list unscheduled pods,reason: %v", err))
+				continue
+			}
+		}
+		if err := w.watch(ctx); err != nil {
+			if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+				//resourceVersion이 만료됨, 처음부터 다시 list
+				logging.Info("Resource version expired, relisting unscheduled pods", "resourceVersion", w.resourceVersion)
+				w.resourceVersion = ""
+				continue
+			}
+			w.fail(ctx, fmt.Errorf("failed to watch unscheduled pods,reason: %v", err))
+		}
+	}
+}
+
+// relist lists the pods, delivers them as added and delivers the pods that
+// disappeared since the last list as deleted.
+func (w *podWatcher) relist(ctx context.Context) error {
+	podList, err := KubeClient.CoreV1().Pods(corev1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: unscheduledPodSelector,
+	})
+	if err != nil {
+		return err
+	}
+
+	listed := make(map[string]*corev1.Pod, len(podList.Items))
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		listed[resource.PodKey(pod)] = pod
+	}
+	for key, pod := range w.known {
+		if _, ok := listed[key]; !ok {
+			if !w.send(ctx, watch.Deleted, pod) {
+				return nil
+			}
+		}
+	}
+	for _, pod := range listed {
+		if !w.send(ctx, watch.Added, pod) {
+			return nil
+		}
+	}
+	w.resourceVersion = podList.ResourceVersion
+	w.backoff = watchInitialBackoff
+	heartbeats.beat(watchLoop)
+	return nil
+}
+
+// watch watches from the last resourceVersion until the server closes the
+// watch, ctx is done or an error event arrives.
+func (w *podWatcher) watch(ctx context.Context) error {
+	watcher, err := KubeClient.CoreV1().Pods(corev1.NamespaceAll).Watch(ctx, metav1.ListOptions{
+		FieldSelector:       unscheduledPodSelector,
+		ResourceVersion:     w.resourceVersion,
+		AllowWatchBookmarks: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		heartbeats.beat(watchLoop)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				//서버 timeout으로 닫힌 경우, 마지막 resourceVersion부터 다시 watch
+				return nil
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified, watch.Deleted:
+				pod, ok := event.Object.(*corev1.Pod)
+				if !ok {
+					continue
+				}
+				w.resourceVersion = pod.ResourceVersion
+				w.backoff = watchInitialBackoff
+				if !w.send(ctx, event.Type, pod) {
+					return nil
+				}
+			case watch.Bookmark:
+				if accessor, err := meta.Accessor(event.Object); err == nil {
+					w.resourceVersion = accessor.GetResourceVersion()
+				}
+			case watch.Error:
+				return apierrors.FromObject(event.Object)
+			}
+		}
+	}
+}
+
+func (w *podWatcher) send(ctx context.Context, eventType watch.EventType, pod *corev1.Pod) bool {
+	key := resource.PodKey(pod)
+	if eventType == watch.Deleted {
+		delete(w.known, key)
+	} else {
+		w.known[key] = pod
+	}
+	select {
+	case w.events <- PodEvent{Type: eventType, Pod: pod}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// fail reports the error and waits for the backoff, which doubles up to
+// watchMaxBackoff until the next event arrives.
+func (w *podWatcher) fail(ctx context.Context, err error) {
+	select {
+	case w.errc <- err:
+	default:
+	}
+
+	//여러 레플리카가 동시에 재시도하지 않도록 jitter 추가
+	wait := w.backoff + time.Duration(rand.Int63n(int64(w.backoff)/2+1))
+	w.backoff *= 2
+	if w.backoff > watchMaxBackoff {
+		w.backoff = watchMaxBackoff
+	}
+	select {
+	case <-time.After(wait):
+	case <-ctx.Done():
+	}
+}
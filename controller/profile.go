@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+
+	"gpu-scheduler/algorithm/predicates"
+	"gpu-scheduler/algorithm/priorities"
+	"gpu-scheduler/config"
+	"gpu-scheduler/logging"
+	resource "gpu-scheduler/resourceinfo"
+	framework "gpu-scheduler/vlalpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Profile schedules the pods whose spec.schedulerName is SchedulerName with
+// its own plugins and metrics source.
+type Profile struct {
+	SchedulerName string
+	Framework     *framework.Framework
+	Metrics       resource.MetricsProvider
+	TieBreak      string
+}
+
+// schedulerName별 프로파일
+var profiles = make(map[string]*Profile)
+
+// NewRegistry returns every plugin the scheduler knows about.
+func NewRegistry() (framework.Registry, error) {
+	registry := framework.Registry{
+		GPUDeviceAllocationName: func() (framework.Plugin, error) { return &GPUDeviceAllocation{}, nil },
+		DefaultBinderName:       func() (framework.Plugin, error) { return &DefaultBinder{}, nil },
+	}
+	if err := registry.Merge(predicates.NewRegistry()); err != nil {
+		return nil, err
+	}
+	if err := registry.Merge(priorities.NewRegistry()); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// InitProfiles builds a framework and a metrics provider for every profile
+// in the configuration and the scheduling queue. GPU allocation and binding
+// are always enabled.
+func InitProfiles(cfg *config.SchedulerConfiguration) error {
+	registry, err := NewRegistry()
+	if err != nil {
+		return err
+	}
+	//리더가 되기 전에도 /debug/queue에서 읽을 수 있도록 미리 생성
+	schedulingQueue = NewSchedulingQueue(cfg.PodInitialBackoff.Duration, cfg.PodMaxBackoff.Duration)
+
+	for _, profileConfig := range cfg.Profiles {
+		profile, err := newProfile(registry, profileConfig)
+		if err != nil {
+			CloseProfiles()
+			return fmt.Errorf("profile %s: %v", profileConfig.SchedulerName, err)
+		}
+		profiles[profile.SchedulerName] = profile
+	}
+	return nil
+}
+
+func newProfile(registry framework.Registry, profileConfig config.Profile) (*Profile, error) {
+	plugins := make([]framework.PluginConfig, 0)
+	for _, name := range profileConfig.Predicates {
+		plugins = append(plugins, framework.PluginConfig{Name: name})
+	}
+	scorePlugins, err := priorities.PriorityWeights(profileConfig.Priorities)
+	if err != nil {
+		return nil, err
+	}
+	plugins = append(plugins, scorePlugins...)
+	plugins = append(plugins,
+		framework.PluginConfig{Name: GPUDeviceAllocationName},
+		framework.PluginConfig{Name: DefaultBinderName},
+	)
+
+	fwk, err := framework.NewFramework(registry, plugins)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := resource.NewMetricsProvider(profileConfig.Metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Profile{
+		SchedulerName: profileConfig.SchedulerName,
+		Framework:     fwk,
+		Metrics:       metrics,
+		TieBreak:      profileConfig.TieBreak,
+	}, nil
+}
+
+// CloseProfiles closes the metrics providers of the profiles.
+func CloseProfiles() {
+	for name, profile := range profiles {
+		if err := profile.Metrics.Close(); err != nil {
+			logging.Error(err, "Failed to close metrics provider", "profile", name)
+		}
+		delete(profiles, name)
+	}
+}
+
+// profileForPod returns the profile responsible for the pod, or nil if the
+// pod belongs to another scheduler.
+func profileForPod(pod *corev1.Pod) *Profile {
+	return profiles[pod.Spec.SchedulerName]
+}
+
+// profileNames returns the scheduler names of the profiles, sorted.
+func profileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
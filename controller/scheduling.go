@@ -18,162 +18,238 @@ import (
 	"fmt"
 	"gpu-scheduler/algorithm/predicates"
 	"gpu-scheduler/algorithm/priorities"
-	"log"
+	"gpu-scheduler/logging"
+	"gpu-scheduler/metrics"
+	"gpu-scheduler/postevent"
+	resource "gpu-scheduler/resourceinfo"
+	framework "gpu-scheduler/vlalpha1"
+	"math/rand"
+	"strconv"
 	"sync"
 	"time"
 
-	"gpu-scheduler/config"
-
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 )
 
-var processorLock = &sync.Mutex{}
+// KubeClient is the clientset shared with the other packages, set in main.
+var KubeClient kubernetes.Interface
 
-//새로 생성된 파드 감시
-func MonitorUnscheduledPods(done chan struct{}, wg *sync.WaitGroup) {
-	fmt.Println("called MonitorUnscheduledPods")
-	pods, errc := WatchUnscheduledPods() //새롭게 들어온 파드 얻음
+var schedulingQueue *SchedulingQueue
+
+//새로 생성된 파드 감시, 스케줄링 큐에 추가
+func MonitorUnscheduledPods(done <-chan struct{}, wg *sync.WaitGroup) {
+	logging.V(2).Info("Started watch loop")
+	heartbeats.start(watchLoop, watchLoopTimeout)
+	defer heartbeats.stop(watchLoop)
+	events, errc := WatchUnscheduledPods(done) //새롭게 들어온 파드 얻음
 	for {
 		select {
 		case err := <-errc:
-			fmt.Println("MonitorUnschedeuledPods>case err: ", err)
-		case pod := <-pods:
-			processorLock.Lock()
-			time.Sleep(2 * time.Second)
-			fmt.Println("Watch->pod->scheduling")
-			err := SchedulePod(pod) //새롭게 들어온 파드 스케줄링
-			if err != nil {
-				fmt.Println("MonitorUnschedeuledPods>case pod: ", err)
+			logging.Error(err, "Failed to watch unscheduled pods")
+		case event := <-events:
+			//프로파일이 없는 schedulerName의 파드는 다른 스케줄러 담당
+			if profileForPod(event.Pod) == nil {
+				//default-scheduler가 아닌 이름은 설정 누락일 수 있으므로 기록
+				if event.Type == watch.Added && event.Pod.Spec.SchedulerName != corev1.DefaultSchedulerName {
+					logging.Info("Skipped pod with no matching profile", "pod", resource.PodKey(event.Pod), "schedulerName", event.Pod.Spec.SchedulerName, "profiles", profileNames())
+				}
+				continue
+			}
+			switch event.Type {
+			case watch.Added:
+				logging.V(2).Info("Queued unscheduled pod", "pod", resource.PodKey(event.Pod), "uid", event.Pod.UID)
+				schedulingQueue.Add(event.Pod)
+			case watch.Modified:
+				schedulingQueue.Update(event.Pod)
+			case watch.Deleted:
+				//삭제되었거나 바인딩된 파드
+				schedulingQueue.Delete(event.Pod)
 			}
-			processorLock.Unlock()
 		case <-done:
 			wg.Done()
-			log.Println("Stopped scheduler.")
+			logging.Info("Stopped watch loop")
 			return
 		}
 	}
 }
 
-//스케줄링 실패한 파드 일정 주기로 재스케줄링
-func ReconcileUnscheduledPods(interval int, done chan struct{}, wg *sync.WaitGroup) {
-	fmt.Println("called ReconcileUnscheduledPods")
+//큐에서 우선순위가 가장 높은 파드부터 하나씩 스케줄링
+func ScheduleQueuedPods(wg *sync.WaitGroup) {
+	logging.V(2).Info("Started scheduling loop")
+	defer wg.Done()
+	var bindings sync.WaitGroup //진행 중인 바인딩이 끝날 때까지 대기
 	for {
-		select {
-		case <-time.After(time.Duration(interval) * time.Second):
-			fmt.Println("called ReconcileUnscheduledPods>time duration")
-			err := SchedulePods()
-			if err != nil {
-				log.Println("ReconcileUnscheduledPods error: ", err)
-			}
-		case <-done:
-			wg.Done()
-			log.Println("Stopped reconciliation loop.")
+		pInfo, cycle := schedulingQueue.Pop()
+		if pInfo == nil {
+			bindings.Wait()
+			logging.Info("Stopped scheduling loop")
 			return
 		}
+		scheduleOne(pInfo, cycle, &bindings)
 	}
 }
 
-func WatchUnscheduledPods() (<-chan *corev1.Pod, <-chan error) {
-	fmt.Println("called WatchUnscheduledPods")
-	pods := make(chan *corev1.Pod)
-	errc := make(chan error, 1)
+// scheduleOne places the pod on a node and assumes it in the cache, then
+// binds it on its own goroutine so the next pod does not wait for the API
+// round-trips.
+func scheduleOne(pInfo *QueuedPodInfo, cycle int64, bindings *sync.WaitGroup) {
+	pod := pInfo.Pod
+	//큐에 있는 동안 다른 경로로 바인딩되었거나 바인딩 중인 파드
+	if resource.Cache.IsAssigned(pod) {
+		schedulingQueue.Done(pod)
+		return
+	}
+	//한 번의 스케줄링 시도에서 남기는 모든 로그에 파드와 시도 ID 포함
+	logger := logging.WithValues("pod", resource.PodKey(pod), "uid", pod.UID, "attempt", newAttemptID())
+	ctx := logging.NewContext(context.TODO(), logger)
+	//시도마다 새로 만들고 바인딩이 끝나면 버림
+	state := framework.NewCycleState()
 
-	host_config, _ := rest.InClusterConfig()
-	host_kubeClient := kubernetes.NewForConfigOrDie(host_config)
+	start := time.Now()
+	profile := profileForPod(pod)
+	if profile == nil {
+		handleSchedulingFailure(ctx, pInfo, cycle, start, fmt.Errorf("no profile for scheduler name %q of pod (%s)", pod.Spec.SchedulerName, pod.ObjectMeta.Name))
+		return
+	}
+	node, err := SchedulePod(ctx, state, profile, pod)
+	if err != nil {
+		handleSchedulingFailure(ctx, pInfo, cycle, start, err)
+		return
+	}
 
+	bindings.Add(1)
 	go func() {
-		for {
-			watch, err := host_kubeClient.CoreV1().Pods("").Watch(context.TODO(), metav1.ListOptions{
-				FieldSelector: fmt.Sprintf("spec.schedulerName=%s,spec.nodeName=", config.SchedulerName),
-			})
+		defer bindings.Done()
+		bindStart := time.Now()
+		err := Binding(ctx, profile.Framework, state, pod, node.Node)
+		metrics.StageDuration.WithLabelValues(metrics.BindStage).Observe(metrics.SinceInSeconds(bindStart))
+		if err != nil {
+			handleSchedulingFailure(ctx, pInfo, cycle, start, err)
+			return
+		}
+
+		schedulingQueue.Done(pod)
+		metrics.ScheduleAttempts.WithLabelValues(metrics.ScheduledResult, pod.Spec.SchedulerName).Inc()
+		metrics.E2ESchedulingDuration.WithLabelValues(metrics.ScheduledResult, pod.Spec.SchedulerName).Observe(metrics.SinceInSeconds(start))
+		logger.Info("Scheduled pod", "result", metrics.ScheduledResult, "node", node.NodeName, "score", node.NodeScore, "attempts", pInfo.Attempts, "duration", time.Since(start))
+		//큐에 처음 들어온 시점부터 바인딩까지, GPU 작업 시작 시간 SLO에 사용
+		metrics.PodSchedulingDuration.WithLabelValues(strconv.Itoa(pInfo.Attempts)).Observe(metrics.SinceInSeconds(pInfo.InitialAttemptTimestamp))
+	}()
+}
+
+// handleSchedulingFailure records the failed attempt, reports it on the pod
+// and puts the pod back into the queue.
+func handleSchedulingFailure(ctx context.Context, pInfo *QueuedPodInfo, cycle int64, start time.Time, err error) {
+	pod := pInfo.Pod
+	logger := logging.FromContext(ctx)
+	result := metrics.ErrorResult
+	if _, ok := err.(*predicates.FitError); ok {
+		result = metrics.UnschedulableResult
+	}
+	metrics.ScheduleAttempts.WithLabelValues(result, pod.Spec.SchedulerName).Inc()
+	metrics.E2ESchedulingDuration.WithLabelValues(result, pod.Spec.SchedulerName).Observe(metrics.SinceInSeconds(start))
+
+	logger.Info("Failed to schedule pod", "result", result, "attempts", pInfo.Attempts, "duration", time.Since(start), "reason", err)
+	//스케줄링 중 삭제된 파드는 이벤트와 상태를 남기지 않고 큐에서 제거
+	if schedulingQueue.IsDeleted(pod) {
+		schedulingQueue.AddUnschedulable(pInfo, cycle)
+		return
+	}
+	postevent.FailedSchedulingEvent(pod, err.Error())
+	if err := updateUnschedulableCondition(ctx, pod, err); err != nil {
+		logger.Error(err, "Failed to update PodScheduled condition")
+	}
+	schedulingQueue.AddUnschedulable(pInfo, cycle)
+}
+
+// newAttemptID returns a short random ID to correlate the records of one
+// scheduling attempt, also across replicas.
+func newAttemptID() string {
+	return fmt.Sprintf("%08x", rand.Uint32())
+}
+
+//watch에서 놓친 파드를 일정 주기로 스케줄링 큐에 추가
+func ReconcileUnscheduledPods(interval time.Duration, done <-chan struct{}, wg *sync.WaitGroup) {
+	logging.V(2).Info("Started reconciliation loop", "interval", interval)
+	//세 번 연속 주기를 놓치면 멈춘 것으로 판단
+	heartbeats.start(reconcileLoop, 3*interval)
+	defer heartbeats.stop(reconcileLoop)
+	for {
+		select {
+		case <-time.After(interval):
+			err := QueueUnscheduledPods()
 			if err != nil {
-				fmt.Println("watchUnscheduledPods error: ", err)
-				errc <- err
-			}
-			for event := range watch.ResultChan() {
-				if event.Type == "ADDED" {
-					fmt.Println("ADDED")
-					pods <- event.Object.(*corev1.Pod)
-				}
+				logging.Error(err, "Failed to reconcile unscheduled pods")
 			}
+			heartbeats.beat(reconcileLoop)
+		case <-done:
+			wg.Done()
+			logging.Info("Stopped reconciliation loop")
+			return
 		}
-	}()
-	return pods, errc
+	}
 }
 
 func GetUnscheduledPods() ([]*corev1.Pod, error) {
-	fmt.Println("called GetUnscheduledPods")
 	rescheduledPods := make([]*corev1.Pod, 0)
 
-	host_config, _ := rest.InClusterConfig()
-	host_kubeClient := kubernetes.NewForConfigOrDie(host_config)
-
-	podList, err := host_kubeClient.CoreV1().Pods(corev1.NamespaceAll).List(context.TODO(), metav1.ListOptions{
-		FieldSelector: fmt.Sprintf("spec.schedulerName=%s,status.phase=Pending", config.SchedulerName),
+	podList, err := KubeClient.CoreV1().Pods(corev1.NamespaceAll).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: unscheduledPodSelector,
 	})
 
 	if err != nil {
-		fmt.Println("getUnscheduledPods error: ", err)
 		return rescheduledPods, err
 	}
 
-	for _, pod := range podList.Items {
-		if pod.Spec.NodeName == "" {
-			fmt.Println("pod.Spec.NodeName: ", pod.Spec.NodeName, "/pod.Spec.SchedulerName: ", pod.Spec.SchedulerName, "/pod.Status.Phase: ", pod.Status.Phase, "/pod.name: ", pod.Name)
-			rescheduledPods = append(rescheduledPods, &pod)
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Spec.NodeName == "" && profileForPod(pod) != nil {
+			rescheduledPods = append(rescheduledPods, pod)
 		}
 	}
 
 	return rescheduledPods, nil
 }
 
-func SchedulePod(pod *corev1.Pod) error {
-	fmt.Println("--------------------------------------------------------------")
-	fmt.Println("newPodName:", pod.ObjectMeta.Name)
+// SchedulePod filters and scores the nodes for the pod and assumes it on the
+// best one, which is returned for binding. ctx carries the logger of the
+// attempt and state its node snapshot and plugin data.
+func SchedulePod(ctx context.Context, state *framework.CycleState, profile *Profile, pod *corev1.Pod) (*resource.NodeInfo, error) {
+	logger := logging.FromContext(ctx)
+	logger.V(2).Info("Attempting to schedule pod", "profile", profile.SchedulerName)
 
-	nodes, err := predicates.Filtering(pod)
+	filterStart := time.Now()
+	nodes, err := predicates.Filtering(ctx, profile.Framework, state, profile.Metrics, pod)
+	metrics.StageDuration.WithLabelValues(metrics.FilterStage).Observe(metrics.SinceInSeconds(filterStart))
 	if err != nil {
-		fmt.Println("schedulePod>Scoring Filtering: ", err)
-		return err
-	}
-
-	if len(nodes) == 0 {
-		return fmt.Errorf("Unable to schedule pod (%s) failed to fit in any node", pod.ObjectMeta.Name)
+		return nil, err
 	}
 
-	bestNode, err := priorities.Scoring(nodes, pod)
+	scoreStart := time.Now()
+	bestNode, err := priorities.Scoring(ctx, profile.Framework, state, profile.TieBreak, nodes, pod)
+	metrics.StageDuration.WithLabelValues(metrics.ScoreStage).Observe(metrics.SinceInSeconds(scoreStart))
 	if err != nil {
-		fmt.Println("schedulePod>Scoring error: ", err)
-		return err
+		return nil, err
 	}
 
-	err = Binding(pod, bestNode.Node)
-	if err != nil {
-		fmt.Println("schedulePod>Binding error: ", err)
-		return err
+	if err := Assume(ctx, profile.Framework, state, pod, bestNode.NodeName); err != nil {
+		return nil, err
 	}
-
-	return nil
+	return bestNode, nil
 }
 
-func SchedulePods() error { //called by reconcileUnscheduledPods
-	fmt.Println("called SchedulePods")
-	processorLock.Lock()
-	defer processorLock.Unlock()
+func QueueUnscheduledPods() error { //called by reconcileUnscheduledPods
 	pods, err := GetUnscheduledPods()
 	if err != nil {
-		log.Println("SchedulePods>getUnscheduledPods error: ", err)
 		return err
 	}
-	for _, pod := range pods { //스케줄링 대기중인 파드들 하나씩 스케줄링
-		fmt.Println("reconcile called schedulepods: ", pod.Name)
-		err := SchedulePod(pod)
-		if err != nil {
-			log.Println("SchedulePods>schedulepod error: ", err)
+	for _, pod := range pods { //큐에 없는 스케줄링 대기 파드만 추가
+		if !schedulingQueue.Has(pod) && !resource.Cache.IsAssigned(pod) {
+			logging.V(2).Info("Queued missed unscheduled pod", "pod", resource.PodKey(pod), "uid", pod.UID)
+			schedulingQueue.Add(pod)
 		}
 	}
 	return nil
@@ -0,0 +1,535 @@
+package controller
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+
+	"gpu-scheduler/logging"
+	"gpu-scheduler/metrics"
+	resource "gpu-scheduler/resourceinfo"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// 파드가 unschedulable 상태로 머무를 수 있는 최대 시간, 이후 이벤트가 없어도 재시도
+const unschedulableQTimeInterval = 60 * time.Second
+
+// QueuedPodInfo is a pod waiting in the scheduling queue.
+type QueuedPodInfo struct {
+	Pod *corev1.Pod
+	// Timestamp is when the pod was added to its current sub-queue.
+	Timestamp time.Time
+	// Attempts is the number of failed scheduling attempts.
+	Attempts int
+	// InitialAttemptTimestamp is when the pod was first added to the queue.
+	InitialAttemptTimestamp time.Time
+}
+
+// SchedulingQueue holds the pods waiting to be scheduled. Pods to try next
+// are in activeQ ordered by priority and creation time. A pod that failed
+// waits in backoffQ until its backoff expires, or in unschedulableQ until a
+// cluster event may make it schedulable.
+type SchedulingQueue struct {
+	lock sync.Mutex
+	cond sync.Cond
+
+	activeQ        *podHeap
+	backoffQ       *podHeap
+	unschedulableQ map[string]*QueuedPodInfo
+	// inFlightPods are the pods popped and not yet done or put back, being
+	// scheduled or bound. The value is true if the pod was deleted meanwhile,
+	// so that it is not put back.
+	inFlightPods map[string]bool
+
+	// schedulingCycle is incremented on every Pop. moveRequestCycle is the
+	// cycle of the last cluster event, so a pod that failed while an event
+	// was handled goes to backoffQ instead of waiting for the next event.
+	schedulingCycle  int64
+	moveRequestCycle int64
+
+	podInitialBackoff time.Duration
+	podMaxBackoff     time.Duration
+
+	closed bool
+}
+
+func NewSchedulingQueue(podInitialBackoff, podMaxBackoff time.Duration) *SchedulingQueue {
+	q := &SchedulingQueue{
+		activeQ:           newPodHeap(activeQLess),
+		unschedulableQ:    make(map[string]*QueuedPodInfo),
+		inFlightPods:      make(map[string]bool),
+		podInitialBackoff: podInitialBackoff,
+		podMaxBackoff:     podMaxBackoff,
+		moveRequestCycle:  -1,
+	}
+	q.backoffQ = newPodHeap(q.backoffQLess)
+	q.cond.L = &q.lock
+	return q
+}
+
+// Run flushes the pods whose backoff expired and the pods left in
+// unschedulableQ for too long until done is closed.
+func (q *SchedulingQueue) Run(done <-chan struct{}) {
+	backoffTicker := time.NewTicker(time.Second)
+	defer backoffTicker.Stop()
+	leftoverTicker := time.NewTicker(30 * time.Second)
+	defer leftoverTicker.Stop()
+
+	for {
+		select {
+		case <-backoffTicker.C:
+			q.flushBackoffQCompleted()
+		case <-leftoverTicker.C:
+			q.flushUnschedulableQLeftover()
+		case <-done:
+			q.Close()
+			return
+		}
+	}
+}
+
+// activeQ: 우선순위가 높은 파드, 같으면 먼저 생성된 파드 먼저
+func activeQLess(a, b *QueuedPodInfo) bool {
+	p1, p2 := podPriority(a.Pod), podPriority(b.Pod)
+	if p1 != p2 {
+		return p1 > p2
+	}
+	t1, t2 := a.Pod.CreationTimestamp.Time, b.Pod.CreationTimestamp.Time
+	if !t1.Equal(t2) {
+		return t1.Before(t2)
+	}
+	return a.Timestamp.Before(b.Timestamp)
+}
+
+// backoffQ: 백오프가 먼저 끝나는 파드 먼저
+func (q *SchedulingQueue) backoffQLess(a, b *QueuedPodInfo) bool {
+	return q.backoffTime(a).Before(q.backoffTime(b))
+}
+
+func podPriority(pod *corev1.Pod) int32 {
+	if pod.Spec.Priority != nil {
+		return *pod.Spec.Priority
+	}
+	return 0
+}
+
+// backoffTime doubles the initial backoff for every failed attempt up to
+// podMaxBackoff.
+func (q *SchedulingQueue) backoffTime(pInfo *QueuedPodInfo) time.Time {
+	duration := q.podInitialBackoff
+	for i := 1; i < pInfo.Attempts; i++ {
+		duration *= 2
+		if duration >= q.podMaxBackoff {
+			duration = q.podMaxBackoff
+			break
+		}
+	}
+	return pInfo.Timestamp.Add(duration)
+}
+
+func (q *SchedulingQueue) isBackingOff(pInfo *QueuedPodInfo) bool {
+	return q.backoffTime(pInfo).After(time.Now())
+}
+
+// Add puts a new pod into activeQ. A pod already in the queue is updated
+// in place and keeps its backoff.
+func (q *SchedulingQueue) Add(pod *corev1.Pod) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	defer q.recordPendingLocked()
+
+	key := resource.PodKey(pod)
+	if q.updateLocked(key, pod) {
+		return
+	}
+	now := time.Now()
+	q.activeQ.Push(&QueuedPodInfo{Pod: pod, Timestamp: now, InitialAttemptTimestamp: now})
+	q.cond.Broadcast()
+}
+
+// Update replaces the pod in whichever sub-queue it is. A pod in
+// unschedulableQ whose spec, labels or annotations changed is moved out
+// since the update may make it schedulable. Updates of a pod that is being
+// scheduled, bound or assumed are skipped, since they mostly come from the
+// scheduler's own writes to the pod.
+func (q *SchedulingQueue) Update(pod *corev1.Pod) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	defer q.recordPendingLocked()
+
+	key := resource.PodKey(pod)
+	if pInfo, ok := q.unschedulableQ[key]; ok {
+		//상태(status)만 바뀐 경우는 다시 시도해도 결과가 같음
+		if isPodUpdated(pInfo.Pod, pod) {
+			delete(q.unschedulableQ, key)
+			pInfo.Pod = pod
+			q.requeueLocked(pInfo)
+		} else {
+			pInfo.Pod = pod
+		}
+		return
+	}
+	if !q.updateLocked(key, pod) {
+		if q.skipPodUpdateLocked(key, pod) {
+			return
+		}
+		now := time.Now()
+		q.activeQ.Push(&QueuedPodInfo{Pod: pod, Timestamp: now, InitialAttemptTimestamp: now})
+		q.cond.Broadcast()
+	}
+}
+
+func (q *SchedulingQueue) updateLocked(key string, pod *corev1.Pod) bool {
+	if pInfo, ok := q.activeQ.Get(key); ok {
+		pInfo.Pod = pod
+		q.activeQ.Update(pInfo)
+		return true
+	}
+	if pInfo, ok := q.backoffQ.Get(key); ok {
+		pInfo.Pod = pod
+		q.backoffQ.Update(pInfo)
+		return true
+	}
+	if pInfo, ok := q.unschedulableQ[key]; ok {
+		pInfo.Pod = pod
+		return true
+	}
+	return false
+}
+
+// skipPodUpdateLocked reports whether the pod outside the sub-queues is in
+// flight or assumed, so that it is not scheduled twice.
+func (q *SchedulingQueue) skipPodUpdateLocked(key string, pod *corev1.Pod) bool {
+	if _, ok := q.inFlightPods[key]; ok {
+		return true
+	}
+	return resource.Cache != nil && resource.Cache.IsAssumed(pod)
+}
+
+func isPodUpdated(oldPod, newPod *corev1.Pod) bool {
+	return !equality.Semantic.DeepEqual(oldPod.Spec, newPod.Spec) ||
+		!equality.Semantic.DeepEqual(oldPod.Labels, newPod.Labels) ||
+		!equality.Semantic.DeepEqual(oldPod.Annotations, newPod.Annotations)
+}
+
+// Delete removes the pod from the queue. A pod in flight is marked deleted
+// so that AddUnschedulable drops it.
+func (q *SchedulingQueue) Delete(pod *corev1.Pod) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	defer q.recordPendingLocked()
+
+	key := resource.PodKey(pod)
+	if _, ok := q.inFlightPods[key]; ok {
+		q.inFlightPods[key] = true
+	}
+	q.activeQ.Delete(key)
+	q.backoffQ.Delete(key)
+	delete(q.unschedulableQ, key)
+}
+
+// Has reports whether the pod is in any sub-queue or in flight.
+func (q *SchedulingQueue) Has(pod *corev1.Pod) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	key := resource.PodKey(pod)
+	if _, ok := q.inFlightPods[key]; ok {
+		return true
+	}
+	if _, ok := q.activeQ.Get(key); ok {
+		return true
+	}
+	if _, ok := q.backoffQ.Get(key); ok {
+		return true
+	}
+	_, ok := q.unschedulableQ[key]
+	return ok
+}
+
+// IsDeleted reports whether the in-flight pod was deleted while it was
+// being scheduled.
+func (q *SchedulingQueue) IsDeleted(pod *corev1.Pod) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.inFlightPods[resource.PodKey(pod)]
+}
+
+// Pop blocks until a pod is in activeQ and returns it with the scheduling
+// cycle it is tried in. It returns nil after Close.
+func (q *SchedulingQueue) Pop() (*QueuedPodInfo, int64) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	defer q.recordPendingLocked()
+
+	for q.activeQ.Len() == 0 {
+		if q.closed {
+			return nil, 0
+		}
+		q.cond.Wait()
+	}
+	pInfo := q.activeQ.Pop()
+	q.inFlightPods[resource.PodKey(pInfo.Pod)] = false
+	pInfo.Attempts++
+	q.schedulingCycle++
+	return pInfo, q.schedulingCycle
+}
+
+// AddUnschedulable puts a pod that failed in podSchedulingCycle back. It
+// goes to backoffQ if a cluster event arrived while it was being scheduled,
+// otherwise it waits in unschedulableQ for the next event. A pod deleted
+// while it was being scheduled is dropped.
+func (q *SchedulingQueue) AddUnschedulable(pInfo *QueuedPodInfo, podSchedulingCycle int64) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	defer q.recordPendingLocked()
+
+	key := resource.PodKey(pInfo.Pod)
+	deleted := q.inFlightPods[key]
+	delete(q.inFlightPods, key)
+	if deleted {
+		logging.V(2).Info("Dropped pod deleted while being scheduled", "pod", key, "uid", pInfo.Pod.UID)
+		return
+	}
+	if _, ok := q.activeQ.Get(key); ok {
+		return
+	}
+	if _, ok := q.backoffQ.Get(key); ok {
+		return
+	}
+
+	pInfo.Timestamp = time.Now()
+	if q.moveRequestCycle >= podSchedulingCycle {
+		q.backoffQ.Push(pInfo)
+		return
+	}
+	q.unschedulableQ[key] = pInfo
+}
+
+// Done marks the popped pod as no longer in flight, once it is bound or
+// skipped.
+func (q *SchedulingQueue) Done(pod *corev1.Pod) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	delete(q.inFlightPods, resource.PodKey(pod))
+}
+
+// MoveAllToActiveOrBackoffQueue moves every pod in unschedulableQ out
+// because of a cluster event that may make them schedulable.
+func (q *SchedulingQueue) MoveAllToActiveOrBackoffQueue(event string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	defer q.recordPendingLocked()
+
+	if len(q.unschedulableQ) > 0 {
+		logging.V(2).Info("Moving unschedulable pods", "pods", len(q.unschedulableQ), "event", event)
+	}
+	for key, pInfo := range q.unschedulableQ {
+		delete(q.unschedulableQ, key)
+		q.requeueLocked(pInfo)
+	}
+	q.moveRequestCycle = q.schedulingCycle
+}
+
+// requeueLocked moves a pod out of unschedulableQ into backoffQ if it is
+// still backing off, otherwise into activeQ.
+func (q *SchedulingQueue) requeueLocked(pInfo *QueuedPodInfo) {
+	if q.isBackingOff(pInfo) {
+		q.backoffQ.Push(pInfo)
+		return
+	}
+	q.activeQ.Push(pInfo)
+	q.cond.Broadcast()
+}
+
+func (q *SchedulingQueue) flushBackoffQCompleted() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	defer q.recordPendingLocked()
+
+	moved := false
+	for q.backoffQ.Len() > 0 {
+		pInfo := q.backoffQ.Peek()
+		if q.isBackingOff(pInfo) {
+			break
+		}
+		q.backoffQ.Pop()
+		q.activeQ.Push(pInfo)
+		moved = true
+	}
+	if moved {
+		q.cond.Broadcast()
+	}
+}
+
+// GPU 메트릭 변화처럼 이벤트가 없는 경우를 위해 오래 머문 파드 재시도
+func (q *SchedulingQueue) flushUnschedulableQLeftover() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	defer q.recordPendingLocked()
+
+	now := time.Now()
+	for key, pInfo := range q.unschedulableQ {
+		if now.Sub(pInfo.Timestamp) > unschedulableQTimeInterval {
+			delete(q.unschedulableQ, key)
+			q.requeueLocked(pInfo)
+		}
+	}
+}
+
+func (q *SchedulingQueue) recordPendingLocked() {
+	metrics.PendingPods.WithLabelValues("active").Set(float64(q.activeQ.Len()))
+	metrics.PendingPods.WithLabelValues("backoff").Set(float64(q.backoffQ.Len()))
+	metrics.PendingPods.WithLabelValues("unschedulable").Set(float64(len(q.unschedulableQ)))
+}
+
+// QueueDump is the state of the queue, for debugging.
+type QueueDump struct {
+	Active        []QueuedPodDump `json:"active"`
+	Backoff       []QueuedPodDump `json:"backoff"`
+	Unschedulable []QueuedPodDump `json:"unschedulable"`
+}
+
+type QueuedPodDump struct {
+	Pod                     string    `json:"pod"`
+	SchedulerName           string    `json:"schedulerName"`
+	Priority                int32     `json:"priority"`
+	Attempts                int       `json:"attempts"`
+	Timestamp               time.Time `json:"timestamp"`
+	InitialAttemptTimestamp time.Time `json:"initialAttemptTimestamp"`
+	BackoffExpiration       time.Time `json:"backoffExpiration,omitempty"`
+}
+
+// Dump returns the pods of every sub-queue, activeQ and backoffQ in the
+// order they are popped.
+func (q *SchedulingQueue) Dump() QueueDump {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	unschedulable := make([]*QueuedPodInfo, 0, len(q.unschedulableQ))
+	for _, pInfo := range q.unschedulableQ {
+		unschedulable = append(unschedulable, pInfo)
+	}
+	return QueueDump{
+		Active:        q.dumpPodsLocked(q.activeQ.data.items, activeQLess, false),
+		Backoff:       q.dumpPodsLocked(q.backoffQ.data.items, q.backoffQLess, true),
+		Unschedulable: q.dumpPodsLocked(unschedulable, activeQLess, true),
+	}
+}
+
+func (q *SchedulingQueue) dumpPodsLocked(pInfos []*QueuedPodInfo, less func(a, b *QueuedPodInfo) bool, backoff bool) []QueuedPodDump {
+	sorted := append([]*QueuedPodInfo(nil), pInfos...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return less(sorted[i], sorted[j])
+	})
+
+	dumps := make([]QueuedPodDump, 0, len(sorted))
+	for _, pInfo := range sorted {
+		dump := QueuedPodDump{
+			Pod:                     resource.PodKey(pInfo.Pod),
+			SchedulerName:           pInfo.Pod.Spec.SchedulerName,
+			Priority:                podPriority(pInfo.Pod),
+			Attempts:                pInfo.Attempts,
+			Timestamp:               pInfo.Timestamp,
+			InitialAttemptTimestamp: pInfo.InitialAttemptTimestamp,
+		}
+		if backoff {
+			dump.BackoffExpiration = q.backoffTime(pInfo)
+		}
+		dumps = append(dumps, dump)
+	}
+	return dumps
+}
+
+// Close wakes up Pop so that the scheduling loop can exit.
+func (q *SchedulingQueue) Close() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// podHeap is a heap of pods that can also be looked up by pod key.
+type podHeap struct {
+	data *podHeapData
+}
+
+type podHeapData struct {
+	items []*QueuedPodInfo
+	index map[string]int
+	less  func(a, b *QueuedPodInfo) bool
+}
+
+func newPodHeap(less func(a, b *QueuedPodInfo) bool) *podHeap {
+	return &podHeap{data: &podHeapData{index: make(map[string]int), less: less}}
+}
+
+func (d *podHeapData) Len() int           { return len(d.items) }
+func (d *podHeapData) Less(i, j int) bool { return d.less(d.items[i], d.items[j]) }
+
+func (d *podHeapData) Swap(i, j int) {
+	d.items[i], d.items[j] = d.items[j], d.items[i]
+	d.index[resource.PodKey(d.items[i].Pod)] = i
+	d.index[resource.PodKey(d.items[j].Pod)] = j
+}
+
+func (d *podHeapData) Push(x interface{}) {
+	pInfo := x.(*QueuedPodInfo)
+	d.index[resource.PodKey(pInfo.Pod)] = len(d.items)
+	d.items = append(d.items, pInfo)
+}
+
+func (d *podHeapData) Pop() interface{} {
+	n := len(d.items)
+	pInfo := d.items[n-1]
+	d.items = d.items[:n-1]
+	delete(d.index, resource.PodKey(pInfo.Pod))
+	return pInfo
+}
+
+func (h *podHeap) Len() int {
+	return h.data.Len()
+}
+
+func (h *podHeap) Get(key string) (*QueuedPodInfo, bool) {
+	i, ok := h.data.index[key]
+	if !ok {
+		return nil, false
+	}
+	return h.data.items[i], true
+}
+
+// Push adds the pod, or updates it if a pod with the same key exists.
+func (h *podHeap) Push(pInfo *QueuedPodInfo) {
+	if _, ok := h.data.index[resource.PodKey(pInfo.Pod)]; ok {
+		h.Update(pInfo)
+		return
+	}
+	heap.Push(h.data, pInfo)
+}
+
+func (h *podHeap) Update(pInfo *QueuedPodInfo) {
+	i := h.data.index[resource.PodKey(pInfo.Pod)]
+	h.data.items[i] = pInfo
+	heap.Fix(h.data, i)
+}
+
+func (h *podHeap) Delete(key string) {
+	if i, ok := h.data.index[key]; ok {
+		heap.Remove(h.data, i)
+	}
+}
+
+func (h *podHeap) Peek() *QueuedPodInfo {
+	if len(h.data.items) == 0 {
+		return nil
+	}
+	return h.data.items[0]
+}
+
+func (h *podHeap) Pop() *QueuedPodInfo {
+	return heap.Pop(h.data).(*QueuedPodInfo)
+}
@@ -0,0 +1,295 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	resource "gpu-scheduler/resourceinfo"
+	st "gpu-scheduler/testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func queuedPod(name string, priority int32) *corev1.Pod {
+	return st.MakePod().Name(name).UID(name).Priority(priority).Obj()
+}
+
+// subQueue returns the sub-queue the pod is in, or "" if it is in none.
+func subQueue(q *SchedulingQueue, pod *corev1.Pod) string {
+	key := resource.PodKey(pod)
+	if _, ok := q.activeQ.Get(key); ok {
+		return "active"
+	}
+	if _, ok := q.backoffQ.Get(key); ok {
+		return "backoff"
+	}
+	if _, ok := q.unschedulableQ[key]; ok {
+		return "unschedulable"
+	}
+	return ""
+}
+
+func TestSchedulingQueueBackoffTime(t *testing.T) {
+	q := NewSchedulingQueue(time.Second, 10*time.Second)
+	now := time.Now()
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 3, want: 4 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+		{attempts: 5, want: 10 * time.Second},
+		{attempts: 10, want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		pInfo := &QueuedPodInfo{Pod: queuedPod("p", 0), Timestamp: now, Attempts: tt.attempts}
+		if got := q.backoffTime(pInfo).Sub(now); got != tt.want {
+			t.Errorf("backoffTime(attempts=%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestSchedulingQueuePopOrder(t *testing.T) {
+	q := NewSchedulingQueue(time.Second, 10*time.Second)
+	q.Add(queuedPod("low", 0))
+	q.Add(queuedPod("high", 100))
+	q.Add(queuedPod("mid", 10))
+
+	for _, want := range []string{"high", "mid", "low"} {
+		pInfo, _ := q.Pop()
+		if pInfo.Pod.Name != want {
+			t.Errorf("Pop() = %s, want %s", pInfo.Pod.Name, want)
+		}
+		if pInfo.Attempts != 1 {
+			t.Errorf("Pop() attempts of %s = %d, want 1", want, pInfo.Attempts)
+		}
+	}
+}
+
+func TestSchedulingQueueAddUnschedulable(t *testing.T) {
+	tests := []struct {
+		name       string
+		moveDuring bool //a cluster event arrives while the pod is being scheduled
+		want       string
+	}{
+		{name: "no event waits for one", want: "unschedulable"},
+		{name: "event during the attempt backs off", moveDuring: true, want: "backoff"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewSchedulingQueue(time.Second, 10*time.Second)
+			pod := queuedPod("p", 0)
+			q.Add(pod)
+			pInfo, cycle := q.Pop()
+			if tt.moveDuring {
+				q.MoveAllToActiveOrBackoffQueue(resource.NodeAddEvent)
+			}
+			q.AddUnschedulable(pInfo, cycle)
+
+			if got := subQueue(q, pod); got != tt.want {
+				t.Errorf("pod is in %q, want %q", got, tt.want)
+			}
+			if _, ok := q.inFlightPods[resource.PodKey(pod)]; ok {
+				t.Errorf("pod is still in flight")
+			}
+		})
+	}
+}
+
+func TestSchedulingQueueDeleteInFlight(t *testing.T) {
+	tests := []struct {
+		name       string
+		moveDuring bool
+		recreate   bool //a pod with the same name is created after the deletion
+		want       string
+	}{
+		{name: "deleted pod is not put back", want: ""},
+		{name: "deleted pod is not backed off after an event", moveDuring: true, want: ""},
+		{name: "recreated pod stays queued", recreate: true, want: "active"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewSchedulingQueue(time.Second, 10*time.Second)
+			pod := queuedPod("p", 0)
+			q.Add(pod)
+			pInfo, cycle := q.Pop()
+			if q.IsDeleted(pod) {
+				t.Fatalf("IsDeleted() = true before Delete")
+			}
+			q.Delete(pod)
+			if !q.IsDeleted(pod) {
+				t.Errorf("IsDeleted() = false after Delete")
+			}
+			if tt.moveDuring {
+				q.MoveAllToActiveOrBackoffQueue(resource.NodeAddEvent)
+			}
+			if tt.recreate {
+				q.Add(st.MakePod().Name("p").UID("p-2").Obj())
+			}
+			q.AddUnschedulable(pInfo, cycle)
+
+			if got := subQueue(q, pod); got != tt.want {
+				t.Errorf("pod is in %q, want %q", got, tt.want)
+			}
+			if _, ok := q.inFlightPods[resource.PodKey(pod)]; ok {
+				t.Errorf("pod is still in flight")
+			}
+			if got := q.Has(pod); got != (tt.want != "") {
+				t.Errorf("Has() = %v, want %v", got, tt.want != "")
+			}
+		})
+	}
+}
+
+func TestSchedulingQueueMoveAllToActiveOrBackoffQueue(t *testing.T) {
+	q := NewSchedulingQueue(time.Second, 10*time.Second)
+	backingOff := &QueuedPodInfo{Pod: queuedPod("backing-off", 0), Timestamp: time.Now(), Attempts: 1}
+	expired := &QueuedPodInfo{Pod: queuedPod("expired", 0), Timestamp: time.Now().Add(-time.Minute), Attempts: 1}
+	q.unschedulableQ[resource.PodKey(backingOff.Pod)] = backingOff
+	q.unschedulableQ[resource.PodKey(expired.Pod)] = expired
+
+	q.MoveAllToActiveOrBackoffQueue(resource.NodeAddEvent)
+
+	if got := subQueue(q, backingOff.Pod); got != "backoff" {
+		t.Errorf("backing-off pod is in %q, want backoff", got)
+	}
+	if got := subQueue(q, expired.Pod); got != "active" {
+		t.Errorf("expired pod is in %q, want active", got)
+	}
+	if q.moveRequestCycle != q.schedulingCycle {
+		t.Errorf("moveRequestCycle = %d, want %d", q.moveRequestCycle, q.schedulingCycle)
+	}
+}
+
+func TestSchedulingQueueFlush(t *testing.T) {
+	q := NewSchedulingQueue(time.Second, 10*time.Second)
+	now := time.Now()
+	pods := []struct {
+		pInfo *QueuedPodInfo
+		in    string
+		want  string
+	}{
+		{pInfo: &QueuedPodInfo{Pod: queuedPod("backoff-done", 0), Timestamp: now.Add(-2 * time.Second), Attempts: 1}, in: "backoff", want: "active"},
+		{pInfo: &QueuedPodInfo{Pod: queuedPod("backoff-pending", 0), Timestamp: now, Attempts: 1}, in: "backoff", want: "backoff"},
+		{pInfo: &QueuedPodInfo{Pod: queuedPod("leftover", 0), Timestamp: now.Add(-2 * unschedulableQTimeInterval), Attempts: 1}, in: "unschedulable", want: "active"},
+		{pInfo: &QueuedPodInfo{Pod: queuedPod("recent", 0), Timestamp: now, Attempts: 1}, in: "unschedulable", want: "unschedulable"},
+	}
+	for _, p := range pods {
+		if p.in == "backoff" {
+			q.backoffQ.Push(p.pInfo)
+		} else {
+			q.unschedulableQ[resource.PodKey(p.pInfo.Pod)] = p.pInfo
+		}
+	}
+
+	q.flushBackoffQCompleted()
+	q.flushUnschedulableQLeftover()
+
+	for _, p := range pods {
+		if got := subQueue(q, p.pInfo.Pod); got != p.want {
+			t.Errorf("%s is in %q, want %q", p.pInfo.Pod.Name, got, p.want)
+		}
+	}
+}
+
+func TestSchedulingQueueUpdate(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(q *SchedulingQueue, pod *corev1.Pod)
+		update   func(pod *corev1.Pod) *corev1.Pod
+		want     string
+		attempts int
+	}{
+		{
+			name:  "in-flight pod is not requeued",
+			setup: func(q *SchedulingQueue, pod *corev1.Pod) { q.Add(pod); q.Pop() },
+			update: func(pod *corev1.Pod) *corev1.Pod {
+				pod = pod.DeepCopy()
+				pod.Annotations = map[string]string{resource.UUIDAnnotation: "gpu-0"}
+				return pod
+			},
+			want: "",
+		},
+		{
+			name: "spec change moves an unschedulable pod out",
+			setup: func(q *SchedulingQueue, pod *corev1.Pod) {
+				q.Add(pod)
+				pInfo, cycle := q.Pop()
+				q.AddUnschedulable(pInfo, cycle)
+				//백오프가 끝난 파드
+				pInfo.Timestamp = time.Now().Add(-time.Minute)
+			},
+			update: func(pod *corev1.Pod) *corev1.Pod {
+				pod = pod.DeepCopy()
+				pod.Spec.NodeSelector = map[string]string{"gpu": "a100"}
+				return pod
+			},
+			want:     "active",
+			attempts: 1,
+		},
+		{
+			name: "status change keeps an unschedulable pod waiting",
+			setup: func(q *SchedulingQueue, pod *corev1.Pod) {
+				q.Add(pod)
+				pInfo, cycle := q.Pop()
+				q.AddUnschedulable(pInfo, cycle)
+			},
+			update: func(pod *corev1.Pod) *corev1.Pod {
+				pod = pod.DeepCopy()
+				pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionFalse}}
+				return pod
+			},
+			want:     "unschedulable",
+			attempts: 1,
+		},
+		{
+			name:   "unknown pod is added",
+			setup:  func(q *SchedulingQueue, pod *corev1.Pod) {},
+			update: func(pod *corev1.Pod) *corev1.Pod { return pod },
+			want:   "active",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewSchedulingQueue(time.Second, 10*time.Second)
+			pod := queuedPod("p", 0)
+			tt.setup(q, pod)
+			q.Update(tt.update(pod))
+
+			if got := subQueue(q, pod); got != tt.want {
+				t.Fatalf("pod is in %q, want %q", got, tt.want)
+			}
+			if tt.want == "" {
+				return
+			}
+			key := resource.PodKey(pod)
+			var pInfo *QueuedPodInfo
+			switch tt.want {
+			case "active":
+				pInfo, _ = q.activeQ.Get(key)
+			case "unschedulable":
+				pInfo = q.unschedulableQ[key]
+			}
+			if pInfo.Attempts != tt.attempts {
+				t.Errorf("attempts = %d, want %d", pInfo.Attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestSchedulingQueueDone(t *testing.T) {
+	q := NewSchedulingQueue(time.Second, 10*time.Second)
+	pod := queuedPod("p", 0)
+	q.Add(pod)
+	q.Pop()
+	if !q.Has(pod) {
+		t.Errorf("Has() = false for an in-flight pod")
+	}
+
+	q.Done(pod)
+	if q.Has(pod) {
+		t.Errorf("Has() = true after Done")
+	}
+}
@@ -4,7 +4,10 @@ go 1.16
 
 require (
 	github.com/influxdata/influxdb1-client v0.0.0-20200827194710-b269163b24ab
+	github.com/prometheus/client_golang v1.11.0
 	k8s.io/api v0.21.3
 	k8s.io/apimachinery v0.21.3
 	k8s.io/client-go v0.21.3
+	k8s.io/component-helpers v0.21.3
+	sigs.k8s.io/yaml v1.2.0
 )
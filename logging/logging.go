@@ -0,0 +1,209 @@
+// Package logging writes structured, leveled log records as logfmt or JSON.
+//
+// Verbosity levels used by the scheduler:
+//
+//	0  one summary record per scheduling decision, errors and lifecycle
+//	2  scheduling loop and queue activity
+//	4  per-node filter results and node scores of every attempt
+//	5  per-plugin score breakdown of every node
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	LogfmtFormat = "logfmt"
+	JSONFormat   = "json"
+)
+
+var (
+	mu        sync.Mutex
+	out       io.Writer = os.Stderr
+	format              = LogfmtFormat
+	verbosity int
+)
+
+// Configure sets the output format and the highest verbosity level written.
+func Configure(logFormat string, v int) error {
+	if logFormat != LogfmtFormat && logFormat != JSONFormat {
+		return fmt.Errorf("unsupported log format %q, must be %s or %s", logFormat, LogfmtFormat, JSONFormat)
+	}
+	if v < 0 {
+		return fmt.Errorf("verbosity must be non-negative, got %d", v)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	format = logFormat
+	verbosity = v
+	return nil
+}
+
+// Logger writes records carrying its key/value pairs.
+type Logger struct {
+	values []interface{}
+}
+
+// WithValues returns a logger adding the key/value pairs to every record.
+func (l Logger) WithValues(keysAndValues ...interface{}) Logger {
+	values := make([]interface{}, 0, len(l.values)+len(keysAndValues))
+	values = append(values, l.values...)
+	values = append(values, keysAndValues...)
+	return Logger{values: values}
+}
+
+func (l Logger) Info(msg string, keysAndValues ...interface{}) {
+	l.write("info", msg, keysAndValues)
+}
+
+func (l Logger) Error(err error, msg string, keysAndValues ...interface{}) {
+	l.write("error", msg, append([]interface{}{"err", err}, keysAndValues...))
+}
+
+// V returns a logger that writes only if the verbosity is at least level.
+func (l Logger) V(level int) Verbose {
+	mu.Lock()
+	defer mu.Unlock()
+	return Verbose{logger: l, enabled: level <= verbosity}
+}
+
+// Verbose is a logger enabled by the verbosity level.
+type Verbose struct {
+	logger  Logger
+	enabled bool
+}
+
+// Enabled reports whether the records are written, so that callers can skip
+// building expensive values.
+func (v Verbose) Enabled() bool {
+	return v.enabled
+}
+
+func (v Verbose) Info(msg string, keysAndValues ...interface{}) {
+	if v.enabled {
+		v.logger.write("debug", msg, keysAndValues)
+	}
+}
+
+func (l Logger) write(level, msg string, keysAndValues []interface{}) {
+	kvs := make([]interface{}, 0, 6+len(l.values)+len(keysAndValues))
+	kvs = append(kvs, "ts", time.Now().Format(time.RFC3339Nano), "level", level, "msg", msg)
+	kvs = append(kvs, l.values...)
+	kvs = append(kvs, keysAndValues...)
+	if len(kvs)%2 != 0 {
+		kvs = append(kvs, "(MISSING)")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var buf bytes.Buffer
+	if format == JSONFormat {
+		encodeJSON(&buf, kvs)
+	} else {
+		encodeLogfmt(&buf, kvs)
+	}
+	out.Write(buf.Bytes())
+}
+
+func encodeLogfmt(buf *bytes.Buffer, kvs []interface{}) {
+	for i := 0; i < len(kvs); i += 2 {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(fmt.Sprint(kvs[i]))
+		buf.WriteByte('=')
+		s := fmt.Sprint(value(kvs[i+1]))
+		if needsQuote(s) {
+			s = strconv.Quote(s)
+		}
+		buf.WriteString(s)
+	}
+	buf.WriteByte('\n')
+}
+
+func encodeJSON(buf *bytes.Buffer, kvs []interface{}) {
+	buf.WriteByte('{')
+	for i := 0; i < len(kvs); i += 2 {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(fmt.Sprint(kvs[i]))
+		buf.Write(key)
+		buf.WriteByte(':')
+		v, err := json.Marshal(value(kvs[i+1]))
+		if err != nil {
+			v, _ = json.Marshal(fmt.Sprint(kvs[i+1]))
+		}
+		buf.Write(v)
+	}
+	buf.WriteString("}\n")
+}
+
+// value converts the values that do not print or encode well by themselves.
+func value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case time.Duration:
+		return v.String()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return v
+}
+
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if unicode.IsSpace(r) || r == '=' || r == '"' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+var root = Logger{}
+
+func Info(msg string, keysAndValues ...interface{}) {
+	root.Info(msg, keysAndValues...)
+}
+
+func Error(err error, msg string, keysAndValues ...interface{}) {
+	root.Error(err, msg, keysAndValues...)
+}
+
+func V(level int) Verbose {
+	return root.V(level)
+}
+
+// WithValues returns a logger adding the key/value pairs to every record.
+func WithValues(keysAndValues ...interface{}) Logger {
+	return root.WithValues(keysAndValues...)
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the logger, so that every record of
+// one scheduling attempt carries the same pod and attempt values.
+func NewContext(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger of the context, or the root logger.
+func FromContext(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(contextKey{}).(Logger); ok {
+		return logger
+	}
+	return root
+}
@@ -14,35 +14,117 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"gpu-scheduler/client"
+	"gpu-scheduler/config"
 	"gpu-scheduler/controller"
-	"log"
+	"gpu-scheduler/logging"
+	"gpu-scheduler/metrics"
+	"gpu-scheduler/postevent"
+	resource "gpu-scheduler/resourceinfo"
+	"math/rand"
+	"net/http"
 	"os"
 	"os/signal"
-	"sync"
 	"syscall"
+	"time"
 )
 
 func main() {
-	log.Println("-----Start GPU Scheduler-----")
+	var configFile string
+	clientOptions := client.NewOptions()
+	flag.StringVar(&configFile, "config", "", "Path to the GPUSchedulerConfiguration file, the default profile is used if empty")
+	flag.StringVar(&clientOptions.Kubeconfig, "kubeconfig", "", "Path to a kubeconfig, only required when running out of cluster")
+	flag.StringVar(&clientOptions.Context, "context", "", "Context of the kubeconfig to use")
+	flag.StringVar(&clientOptions.Master, "master", "", "Address of the Kubernetes API server, overrides the kubeconfig")
+	var qps float64
+	flag.Float64Var(&qps, "kube-api-qps", float64(clientOptions.QPS), "QPS to use while talking with the API server")
+	flag.IntVar(&clientOptions.Burst, "kube-api-burst", clientOptions.Burst, "Burst to use while talking with the API server")
+	var logFormat string
+	var verbosity int
+	flag.StringVar(&logFormat, "log-format", logging.LogfmtFormat, "Log format, logfmt or json")
+	flag.IntVar(&verbosity, "v", 0, "Log verbosity, 4 or higher logs per-node filter results and scores")
+	flag.Parse()
+	clientOptions.QPS = float32(qps)
 
-	doneChan := make(chan struct{}) //struct타입을 전송할 수 있는 통신용 채널 생성
-	var wg sync.WaitGroup           //모든 고루틴이 종료될 때 까지 대기할 때 사용
+	if err := logging.Configure(logFormat, verbosity); err != nil {
+		fatal(err, "Invalid logging flags")
+	}
+	logging.Info("Starting GPU scheduler")
+
+	cfg := config.DefaultConfiguration()
+	if configFile != "" {
+		var err error
+		cfg, err = config.LoadConfiguration(configFile)
+		if err != nil {
+			fatal(err, "Failed to load scheduler config", "config", configFile)
+		}
+	}
+	config.MPSClientsPerGPU = cfg.MPSClientsPerGPU
+	config.Parallelism = cfg.Parallelism
+	config.PercentageOfNodesToScore = cfg.PercentageOfNodesToScore
+
+	if err := controller.InitProfiles(cfg); err != nil {
+		fatal(err, "Failed to build scheduler profiles")
+	}
+	rand.Seed(time.Now().UnixNano())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	//clientset 생성 전에 등록해야 API 요청 결과가 기록됨
+	metrics.RegisterClientMetrics()
+	metrics.Registry.MustRegister(resource.NewGPUAllocationCollector(resource.Allocator))
 
-	wg.Add(1)                                           //대기 중인 고루틴 개수 추가
-	go controller.MonitorUnscheduledPods(doneChan, &wg) //새로 들어온 파드 감시 루틴
+	//모든 패키지가 같은 clientset을 사용
+	host_kubeClient, err := clientOptions.NewClientset()
+	if err != nil {
+		fatal(err, "Failed to create kubernetes client")
+	}
+	controller.KubeClient = host_kubeClient
+	postevent.StartRecording(host_kubeClient)
+
+	//노드/파드 캐시 동기화, 기존 파드의 GPU 할당 정보도 이때 복구됨
+	//대기 중인 레플리카도 캐시를 유지해 리더가 되면 바로 스케줄링
+	resource.Cache = resource.NewSchedulerCache(host_kubeClient, 0)
+
+	//리더가 아닌 레플리카도 메트릭, 상태 확인 제공, 캐시 동기화 중에는 /readyz 실패
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	controller.InstallHandlers(mux)
+	server := &http.Server{Addr: cfg.MetricsBindAddress, Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fatal(err, "Failed to serve HTTP", "address", cfg.MetricsBindAddress)
+		}
+	}()
 
-	wg.Add(1)
-	go controller.ReconcileUnscheduledPods(30, doneChan, &wg) //스케줄링 실패한 파드 30초 간격 재 스케줄링
+	if err := resource.Cache.Run(ctx.Done()); err != nil {
+		fatal(err, "Failed to sync scheduler cache")
+	}
 
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM) //SIGINT를 지정하여 기다리는 루틴
-	for {
-		select { //select문은 case에 채널이 사용됨
-		case <-signalChan:
-			log.Printf("Shutdown signal received, exiting...")
-			close(doneChan)
-			wg.Wait() //모든 고루틴이 종료될 때까지 대기
-			os.Exit(0)
+	go func() {
+		<-signalChan
+		logging.Info("Shutdown signal received, exiting")
+		cancel()
+	}()
+
+	if *cfg.LeaderElection.LeaderElect {
+		if err := controller.RunWithLeaderElection(ctx, cfg); err != nil {
+			fatal(err, "Failed to run leader election")
 		}
+	} else {
+		controller.Run(ctx, cfg)
 	}
+	controller.CloseProfiles() //모든 고루틴이 종료된 뒤 정리
+	postevent.Shutdown()
+	server.Close()
+}
+
+func fatal(err error, msg string, keysAndValues ...interface{}) {
+	logging.Error(err, msg, keysAndValues...)
+	os.Exit(1)
 }
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+
+	clientmetrics "k8s.io/client-go/tools/metrics"
+)
+
+// requestResult counts the failed requests of the shared clientset.
+type requestResult struct{}
+
+func (requestResult) Increment(ctx context.Context, code, method, host string) {
+	//2xx 이외의 응답과 연결 실패("<error>")만 기록
+	if strings.HasPrefix(code, "2") {
+		return
+	}
+	APIRequestErrors.WithLabelValues(code, method).Inc()
+}
+
+// RegisterClientMetrics hooks the API request results of client-go. It has
+// to be called before any clientset is created.
+func RegisterClientMetrics() {
+	clientmetrics.Register(clientmetrics.RegisterOpts{
+		RequestResult: requestResult{},
+	})
+}
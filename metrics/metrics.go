@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gpu_scheduler"
+
+// Results of a scheduling attempt.
+const (
+	ScheduledResult     = "scheduled"
+	UnschedulableResult = "unschedulable"
+	ErrorResult         = "error"
+)
+
+// Stages of a scheduling attempt.
+const (
+	FilterStage = "filter"
+	ScoreStage  = "score"
+	BindStage   = "bind"
+)
+
+// Registry holds every metric of the scheduler, served on /metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	ScheduleAttempts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "schedule_attempts_total",
+			Help:      "Number of attempts to schedule pods, by the result and the profile.",
+		}, []string{"result", "profile"})
+
+	E2ESchedulingDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "e2e_scheduling_duration_seconds",
+			Help:      "Latency of one scheduling attempt from filtering to binding.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+		}, []string{"result", "profile"})
+
+	StageDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "scheduling_stage_duration_seconds",
+			Help:      "Latency of the filter, score and bind stages of a scheduling attempt.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+		}, []string{"stage"})
+
+	PodSchedulingDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "pod_scheduling_duration_seconds",
+			Help:      "Latency from a pod entering the scheduling queue until it is bound, over all attempts.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 20),
+		}, []string{"attempts"})
+
+	PendingPods = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "pending_pods",
+			Help:      "Number of pending pods, by the queue (active, backoff, unschedulable).",
+		}, []string{"queue"})
+
+	MetricsQueryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "metrics_provider_query_duration_seconds",
+			Help:      "Latency of node and GPU metric queries, by the provider (influxdb, prometheus, static).",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+		}, []string{"provider"})
+
+	MetricsQueryErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "metrics_provider_query_errors_total",
+			Help:      "Number of failed node and GPU metric queries, by the provider.",
+		}, []string{"provider"})
+
+	APIRequestErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "api_request_errors_total",
+			Help:      "Number of Kubernetes API requests that failed, by the status code and the method.",
+		}, []string{"code", "method"})
+)
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		ScheduleAttempts,
+		E2ESchedulingDuration,
+		StageDuration,
+		PodSchedulingDuration,
+		PendingPods,
+		MetricsQueryDuration,
+		MetricsQueryErrors,
+		APIRequestErrors,
+	)
+}
+
+// Handler serves the metrics of Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// SinceInSeconds gets the time since the specified start in seconds.
+func SinceInSeconds(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
+
+// ObserveMetricsQuery records the latency of a metric query and counts it
+// as an error if err is not nil.
+func ObserveMetricsQuery(provider string, start time.Time, err error) {
+	MetricsQueryDuration.WithLabelValues(provider).Observe(SinceInSeconds(start))
+	if err != nil {
+		MetricsQueryErrors.WithLabelValues(provider).Inc()
+	}
+}
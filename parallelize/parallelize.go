@@ -0,0 +1,75 @@
+// Package parallelize runs independent pieces of work on a bounded number
+// of goroutines.
+package parallelize
+
+import (
+	"context"
+	"sync"
+)
+
+// Until calls doWorkPiece for every piece in [0, pieces) on at most workers
+// goroutines. Once ctx is done the remaining pieces are not started; the
+// caller reads ctx.Err() to tell a finished run from a cancelled one.
+func Until(ctx context.Context, workers, pieces int, doWorkPiece func(piece int)) {
+	if pieces <= 0 {
+		return
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > pieces {
+		workers = pieces
+	}
+
+	toProcess := make(chan int, pieces)
+	for i := 0; i < pieces; i++ {
+		toProcess <- i
+	}
+	close(toProcess)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for piece := range toProcess {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+				doWorkPiece(piece)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// ErrorChannel keeps the first error sent by the workers.
+type ErrorChannel struct {
+	errCh chan error
+}
+
+func NewErrorChannel() *ErrorChannel {
+	return &ErrorChannel{errCh: make(chan error, 1)}
+}
+
+// SendErrorWithCancel keeps err if it is the first one and cancels the run,
+// so the other workers stop taking pieces.
+func (e *ErrorChannel) SendErrorWithCancel(err error, cancel context.CancelFunc) {
+	select {
+	case e.errCh <- err:
+	default:
+	}
+	cancel()
+}
+
+// ReceiveError returns the first error sent, or nil.
+func (e *ErrorChannel) ReceiveError() error {
+	select {
+	case err := <-e.errCh:
+		return err
+	default:
+		return nil
+	}
+}
@@ -1,73 +1,89 @@
 package postevent
 
 import (
-	"context"
 	"fmt"
-	"gpu-scheduler/config"
+	"strings"
+	"sync"
 
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
 )
 
-func MakeNoNodeEvent(newPod *corev1.Pod, message string) *corev1.Event {
-	event := &corev1.Event{
-		Count:          1,
-		Message:        message,
-		Reason:         "FailedScheduling",
-		LastTimestamp:  metav1.Now(),
-		FirstTimestamp: metav1.Now(),
-		Type:           "Warning",
-		Source: corev1.EventSource{
-			Component: config.SchedulerName,
-		},
-		InvolvedObject: corev1.ObjectReference{
-			Kind:      "Pod",
-			Name:      newPod.Name,
-			Namespace: "default",
-			UID:       newPod.UID,
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: newPod.Name + "-",
-			Name:         newPod.Name,
-		},
-	}
-	return event
+// Event reasons of the scheduler.
+const (
+	Scheduled        = "Scheduled"
+	FailedScheduling = "FailedScheduling"
+	Preempted        = "Preempted"
+	GPUAssigned      = "GPUAssigned"
+)
+
+var (
+	mu          sync.Mutex
+	broadcaster record.EventBroadcaster
+	//schedulerName별 recorder, 이벤트의 source component로 사용
+	recorders = make(map[string]record.EventRecorder)
+)
+
+// StartRecording sends the events of every recorder to the API server. The
+// broadcaster creates each event in the namespace of its pod and counts
+// repeats of the same event instead of creating new ones.
+func StartRecording(client kubernetes.Interface) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	broadcaster = record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
 }
 
-func MakeBindEvent(pod *corev1.Pod, message string) *corev1.Event {
-	event := &corev1.Event{
-		Count:          1,
-		Message:        message,
-		Reason:         "Scheduled",
-		LastTimestamp:  metav1.Now(),
-		FirstTimestamp: metav1.Now(),
-		Type:           "Normal",
-		Source: corev1.EventSource{
-			Component: config.SchedulerName,
-		},
-		InvolvedObject: corev1.ObjectReference{
-			Kind:      "Pod",
-			Name:      pod.Name,
-			Namespace: pod.Namespace,
-			UID:       pod.UID,
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: pod.Name + "-",
-			Name:         pod.Name,
-		},
+// Shutdown stops the broadcaster after the queued events are sent.
+func Shutdown() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if broadcaster != nil {
+		broadcaster.Shutdown()
+		broadcaster = nil
+		recorders = make(map[string]record.EventRecorder)
 	}
-	return event
 }
 
-func PostEvent(event *corev1.Event) error {
-	host_config, _ := rest.InClusterConfig()
-	host_kubeClient := kubernetes.NewForConfigOrDie(host_config)
-	_, err := host_kubeClient.CoreV1().Events(event.InvolvedObject.Namespace).Update(context.TODO(), event, metav1.UpdateOptions{})
-	if err != nil {
-		fmt.Println("post event error: ", err)
-		return err
+// Recorder returns the recorder whose events come from schedulerName.
+func Recorder(schedulerName string) record.EventRecorder {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if broadcaster == nil {
+		//StartRecording 전에는 이벤트를 버림
+		return &record.FakeRecorder{}
+	}
+	recorder, ok := recorders[schedulerName]
+	if !ok {
+		recorder = broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: schedulerName})
+		recorders[schedulerName] = recorder
 	}
-	return nil
+	return recorder
+}
+
+func ScheduledEvent(pod *corev1.Pod, nodeName string) {
+	Recorder(pod.Spec.SchedulerName).Eventf(pod, corev1.EventTypeNormal, Scheduled,
+		"Successfully assigned %s/%s to %s", pod.Namespace, pod.Name, nodeName)
+}
+
+func GPUAssignedEvent(pod *corev1.Pod, nodeName string, uuids []string) {
+	Recorder(pod.Spec.SchedulerName).Eventf(pod, corev1.EventTypeNormal, GPUAssigned,
+		"Assigned GPU %s on %s", strings.Join(uuids, ","), nodeName)
+}
+
+func FailedSchedulingEvent(pod *corev1.Pod, message string) {
+	Recorder(pod.Spec.SchedulerName).Event(pod, corev1.EventTypeWarning, FailedScheduling, message)
+}
+
+// PreemptedEvent is recorded on the pod evicted from the node in the cache,
+// so that the user sees why the resources it was given were freed.
+func PreemptedEvent(pod *corev1.Pod, nodeName string, message string) {
+	Recorder(pod.Spec.SchedulerName).Event(pod, corev1.EventTypeNormal, Preempted,
+		fmt.Sprintf("Evicted from node %s: %s", nodeName, message))
 }
@@ -0,0 +1,458 @@
+package resourceinfo
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gpu-scheduler/logging"
+	"gpu-scheduler/postevent"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+var Cache *SchedulerCache
+
+// Cluster events that may make a pending pod schedulable.
+const (
+	NodeAddEvent               = "NodeAdd"
+	NodeUpdateEvent            = "NodeUpdate"
+	AssignedPodAddEvent        = "AssignedPodAdd"
+	AssignedPodTerminatedEvent = "AssignedPodTerminated"
+	AssignedPodDeleteEvent     = "AssignedPodDelete"
+)
+
+// assumedPodTTL is how long an assumed pod whose binding finished is kept
+// until the informer reports it bound.
+const assumedPodTTL = 30 * time.Second
+
+// ClusterEventHandler is called after the cache applied a cluster event.
+type ClusterEventHandler func(event string)
+
+type nodeItem struct {
+	node *corev1.Node
+	pods map[types.UID]*corev1.Pod
+}
+
+// assumedPod is a pod placed on a node by the scheduler that the API server
+// has not yet reported bound.
+type assumedPod struct {
+	bindingFinished bool
+	deadline        time.Time
+}
+
+// SchedulerCache keeps nodes and the pods bound to each node up to date
+// from shared informers, so a scheduling cycle never lists the API server.
+type SchedulerCache struct {
+	mu          sync.RWMutex
+	nodes       map[string]*nodeItem
+	podNodes    map[types.UID]string
+	assumedPods map[types.UID]*assumedPod
+
+	handlers []ClusterEventHandler
+
+	informerFactory informers.SharedInformerFactory
+	nodeInformer    cache.SharedIndexInformer
+	podInformer     cache.SharedIndexInformer
+}
+
+func NewSchedulerCache(client kubernetes.Interface, resync time.Duration) *SchedulerCache {
+	factory := informers.NewSharedInformerFactory(client, resync)
+	c := &SchedulerCache{
+		nodes:           make(map[string]*nodeItem),
+		podNodes:        make(map[types.UID]string),
+		assumedPods:     make(map[types.UID]*assumedPod),
+		informerFactory: factory,
+		nodeInformer:    factory.Core().V1().Nodes().Informer(),
+		podInformer:     factory.Core().V1().Pods().Informer(),
+	}
+
+	c.nodeInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.addNode,
+		UpdateFunc: c.updateNode,
+		DeleteFunc: c.deleteNode,
+	})
+	c.podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.addPod,
+		UpdateFunc: c.updatePod,
+		DeleteFunc: c.deletePod,
+	})
+	return c
+}
+
+// Run starts the informers and blocks until their caches are synced.
+func (c *SchedulerCache) Run(stopCh <-chan struct{}) error {
+	c.informerFactory.Start(stopCh)
+	for informerType, ok := range c.informerFactory.WaitForCacheSync(stopCh) {
+		if !ok {
+			return fmt.Errorf("failed to sync informer cache for %v", informerType)
+		}
+	}
+	go c.runAssumedPodCleanup(stopCh)
+	return nil
+}
+
+func (c *SchedulerCache) runAssumedPodCleanup(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			c.cleanupAssumedPods(now)
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// AddClusterEventHandler registers a handler for the node and pod events
+// that may make pending pods schedulable.
+func (c *SchedulerCache) AddClusterEventHandler(handler ClusterEventHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers = append(c.handlers, handler)
+}
+
+func (c *SchedulerCache) notify(event string) {
+	c.mu.RLock()
+	handlers := c.handlers
+	c.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+func (c *SchedulerCache) HasSynced() bool {
+	return c.nodeInformer.HasSynced() && c.podInformer.HasSynced()
+}
+
+// Snapshot returns every node and the pods bound to it, taken under a
+// single read lock so all nodes reflect the same point in time.
+func (c *SchedulerCache) Snapshot() []*NodeInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	nodeInfoList := make([]*NodeInfo, 0, len(c.nodes))
+	for name, item := range c.nodes {
+		if item.node == nil {
+			continue
+		}
+		pods := make([]*corev1.Pod, 0, len(item.pods))
+		for _, pod := range item.pods {
+			pods = append(pods, pod)
+		}
+		nodeInfoList = append(nodeInfoList, &NodeInfo{
+			NodeName: name,
+			Node:     *item.node,
+			Pods:     pods,
+		})
+	}
+
+	sort.Slice(nodeInfoList, func(i, j int) bool {
+		return nodeInfoList[i].NodeName < nodeInfoList[j].NodeName
+	})
+	return nodeInfoList
+}
+
+// NodeDump is the state of one node in the cache, for debugging.
+type NodeDump struct {
+	Name          string      `json:"name"`
+	Unschedulable bool        `json:"unschedulable,omitempty"`
+	Allocatable   *Resource   `json:"allocatable"`
+	Requested     *Resource   `json:"requested"`
+	Pods          []string    `json:"pods"`
+	AssumedPods   []string    `json:"assumedPods,omitempty"`
+	GPUs          []GPUDevice `json:"gpus"`
+}
+
+// Dump returns every node with its pods, resources and GPUs.
+func (c *SchedulerCache) Dump() []NodeDump {
+	dumps := make([]NodeDump, 0)
+	for _, nodeInfo := range c.Snapshot() {
+		pods := make([]string, 0, len(nodeInfo.Pods))
+		var assumed []string
+		for _, pod := range nodeInfo.Pods {
+			pods = append(pods, PodKey(pod))
+			if c.IsAssumed(pod) {
+				assumed = append(assumed, PodKey(pod))
+			}
+		}
+		sort.Strings(pods)
+		sort.Strings(assumed)
+		dumps = append(dumps, NodeDump{
+			Name:          nodeInfo.NodeName,
+			Unschedulable: nodeInfo.Node.Spec.Unschedulable,
+			Allocatable:   NewResource(nodeInfo.Node.Status.Allocatable),
+			Requested:     GetNodeRequested(nodeInfo.Pods),
+			Pods:          pods,
+			AssumedPods:   assumed,
+			GPUs:          Allocator.Devices(nodeInfo.NodeName),
+		})
+	}
+	return dumps
+}
+
+// PodsOnNode returns the pods bound to the node.
+func (c *SchedulerCache) PodsOnNode(nodeName string) []*corev1.Pod {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, ok := c.nodes[nodeName]
+	if !ok {
+		return nil
+	}
+	pods := make([]*corev1.Pod, 0, len(item.pods))
+	for _, pod := range item.pods {
+		pods = append(pods, pod)
+	}
+	return pods
+}
+
+// AssumePod adds the pod to the node as if it was bound, so that the next
+// scheduling cycles count its resources while the binding is in flight.
+func (c *SchedulerCache) AssumePod(pod *corev1.Pod, nodeName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.podNodes[pod.UID]; ok {
+		return fmt.Errorf("pod %s is already assigned in the cache", PodKey(pod))
+	}
+	assumed := pod.DeepCopy()
+	assumed.Spec.NodeName = nodeName
+	c.getOrCreateItem(nodeName).pods[pod.UID] = assumed
+	c.podNodes[pod.UID] = nodeName
+	c.assumedPods[pod.UID] = &assumedPod{}
+	return nil
+}
+
+// FinishBinding starts the expiry of the assumed pod. It is dropped if the
+// informer does not report it bound within assumedPodTTL.
+func (c *SchedulerCache) FinishBinding(pod *corev1.Pod) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if assumed, ok := c.assumedPods[pod.UID]; ok {
+		assumed.bindingFinished = true
+		assumed.deadline = time.Now().Add(assumedPodTTL)
+	}
+}
+
+// ForgetPod removes an assumed pod whose binding failed and frees its
+// resources for the pending pods.
+func (c *SchedulerCache) ForgetPod(pod *corev1.Pod) {
+	c.mu.Lock()
+	if _, ok := c.assumedPods[pod.UID]; !ok {
+		c.mu.Unlock()
+		return
+	}
+	nodeName := c.podNodes[pod.UID]
+	delete(c.assumedPods, pod.UID)
+	c.removePod(pod.UID)
+	c.mu.Unlock()
+
+	postevent.PreemptedEvent(pod, nodeName, "binding failed")
+	c.notify(AssignedPodDeleteEvent)
+}
+
+func (c *SchedulerCache) cleanupAssumedPods(now time.Time) {
+	expired := make([]*corev1.Pod, 0)
+	c.mu.Lock()
+	for uid, assumed := range c.assumedPods {
+		if !assumed.bindingFinished || now.Before(assumed.deadline) {
+			continue
+		}
+		if item, ok := c.nodes[c.podNodes[uid]]; ok {
+			expired = append(expired, item.pods[uid])
+		}
+		delete(c.assumedPods, uid)
+		c.removePod(uid)
+	}
+	c.mu.Unlock()
+
+	if len(expired) == 0 {
+		return
+	}
+	for _, pod := range expired {
+		//바인딩은 성공했으므로 GPU 할당은 유지, informer 이벤트(setPod/deletePod)에서 정리됨
+		logging.Info("Expired assumed pod", "pod", PodKey(pod), "uid", pod.UID, "node", pod.Spec.NodeName)
+		postevent.PreemptedEvent(pod, pod.Spec.NodeName, fmt.Sprintf("binding was not confirmed within %v", assumedPodTTL))
+	}
+	c.notify(AssignedPodDeleteEvent)
+}
+
+// IsAssumed reports whether the pod is assumed and not yet reported bound.
+func (c *SchedulerCache) IsAssumed(pod *corev1.Pod) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.assumedPods[pod.UID]
+	return ok
+}
+
+// IsAssigned reports whether the cache has seen the pod bound to a node or
+// assumed it while its binding is in flight.
+func (c *SchedulerCache) IsAssigned(pod *corev1.Pod) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.podNodes[pod.UID]
+	return ok
+}
+
+func (c *SchedulerCache) getOrCreateItem(nodeName string) *nodeItem {
+	item, ok := c.nodes[nodeName]
+	if !ok {
+		item = &nodeItem{pods: make(map[types.UID]*corev1.Pod)}
+		c.nodes[nodeName] = item
+	}
+	return item
+}
+
+func (c *SchedulerCache) setNode(node *corev1.Node) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.getOrCreateItem(node.Name).node = node
+}
+
+func (c *SchedulerCache) addNode(obj interface{}) {
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		return
+	}
+	c.setNode(node)
+	c.notify(NodeAddEvent)
+}
+
+func (c *SchedulerCache) updateNode(oldObj, newObj interface{}) {
+	oldNode, ok := oldObj.(*corev1.Node)
+	if !ok {
+		return
+	}
+	newNode, ok := newObj.(*corev1.Node)
+	if !ok {
+		return
+	}
+	c.setNode(newNode)
+	//heartbeat처럼 스케줄링에 영향 없는 변경은 무시
+	if nodeSchedulingPropertiesChanged(oldNode, newNode) {
+		c.notify(NodeUpdateEvent)
+	}
+}
+
+func nodeSchedulingPropertiesChanged(oldNode, newNode *corev1.Node) bool {
+	return oldNode.Spec.Unschedulable != newNode.Spec.Unschedulable ||
+		!equality.Semantic.DeepEqual(oldNode.Status.Allocatable, newNode.Status.Allocatable) ||
+		!equality.Semantic.DeepEqual(oldNode.Labels, newNode.Labels) ||
+		!equality.Semantic.DeepEqual(oldNode.Spec.Taints, newNode.Spec.Taints)
+}
+
+func (c *SchedulerCache) deleteNode(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.nodes[node.Name]
+	if !ok {
+		return
+	}
+	//파드가 남아있으면 파드 삭제 이벤트까지 유지
+	if len(item.pods) == 0 {
+		delete(c.nodes, node.Name)
+	} else {
+		item.node = nil
+	}
+}
+
+func (c *SchedulerCache) addPod(obj interface{}) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	if event := c.setPod(pod); event != "" {
+		c.notify(event)
+	}
+}
+
+func (c *SchedulerCache) updatePod(oldObj, newObj interface{}) {
+	c.addPod(newObj)
+}
+
+// setPod indexes the pod by its node and returns the event to notify.
+func (c *SchedulerCache) setPod(pod *corev1.Pod) string {
+	Ledger.Observe(pod)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, wasAssigned := c.podNodes[pod.UID]
+	if _, ok := c.assumedPods[pod.UID]; ok {
+		if pod.Spec.NodeName == "" {
+			//바인딩 중 어노테이션이 바뀐 경우, 가정한 노드를 유지
+			return ""
+		}
+		//API 서버가 바인딩을 확인함
+		delete(c.assumedPods, pod.UID)
+	}
+	c.removePod(pod.UID)
+	if pod.Spec.NodeName == "" {
+		return ""
+	}
+	if IsTerminated(pod) {
+		//종료된 파드의 자원이 반환됨
+		if wasAssigned {
+			return AssignedPodTerminatedEvent
+		}
+		return ""
+	}
+	c.getOrCreateItem(pod.Spec.NodeName).pods[pod.UID] = pod
+	c.podNodes[pod.UID] = pod.Spec.NodeName
+	if !wasAssigned {
+		return AssignedPodAddEvent
+	}
+	return ""
+}
+
+func (c *SchedulerCache) deletePod(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	Ledger.Release(pod)
+
+	c.mu.Lock()
+	_, wasAssigned := c.podNodes[pod.UID]
+	delete(c.assumedPods, pod.UID)
+	c.removePod(pod.UID)
+	c.mu.Unlock()
+
+	if wasAssigned {
+		c.notify(AssignedPodDeleteEvent)
+	}
+}
+
+func (c *SchedulerCache) removePod(uid types.UID) {
+	nodeName, ok := c.podNodes[uid]
+	if !ok {
+		return
+	}
+	delete(c.podNodes, uid)
+
+	item, ok := c.nodes[nodeName]
+	if !ok {
+		return
+	}
+	delete(item.pods, uid)
+	if item.node == nil && len(item.pods) == 0 {
+		delete(c.nodes, nodeName)
+	}
+}
@@ -0,0 +1,173 @@
+package resourceinfo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	st "gpu-scheduler/testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// newTestCache returns a cache without informers whose events are recorded.
+func newTestCache(events *[]string) *SchedulerCache {
+	c := &SchedulerCache{
+		nodes:       make(map[string]*nodeItem),
+		podNodes:    make(map[types.UID]string),
+		assumedPods: make(map[types.UID]*assumedPod),
+	}
+	c.AddClusterEventHandler(func(event string) { *events = append(*events, event) })
+	return c
+}
+
+func podNames(pods []*corev1.Pod) []string {
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	return names
+}
+
+func TestSchedulerCacheAssumeThenBind(t *testing.T) {
+	var events []string
+	c := newTestCache(&events)
+	pod := st.MakePod().Name("a").UID("a").Obj()
+
+	if err := c.AssumePod(pod, "node-1"); err != nil {
+		t.Fatalf("AssumePod() error = %v", err)
+	}
+	if err := c.AssumePod(pod, "node-2"); err == nil {
+		t.Errorf("AssumePod() of an assumed pod, want error")
+	}
+	if got := podNames(c.PodsOnNode("node-1")); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("PodsOnNode() = %v, want [a]", got)
+	}
+	c.FinishBinding(pod)
+
+	//informer가 바인딩된 파드를 전달
+	c.addPod(st.MakePod().Name("a").UID("a").Node("node-1").Phase(corev1.PodPending).Obj())
+	if c.IsAssumed(pod) || !c.IsAssigned(pod) {
+		t.Errorf("IsAssumed() = %v, IsAssigned() = %v, want false, true", c.IsAssumed(pod), c.IsAssigned(pod))
+	}
+
+	//확인된 파드는 만료되지 않음
+	c.cleanupAssumedPods(time.Now().Add(2 * assumedPodTTL))
+	if got := podNames(c.PodsOnNode("node-1")); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("PodsOnNode() after cleanup = %v, want [a]", got)
+	}
+	if len(events) != 0 {
+		t.Errorf("events = %v, want none", events)
+	}
+}
+
+func TestSchedulerCacheAssumeThenForget(t *testing.T) {
+	var events []string
+	c := newTestCache(&events)
+	pod := st.MakePod().Name("a").UID("a").Obj()
+
+	if err := c.AssumePod(pod, "node-1"); err != nil {
+		t.Fatalf("AssumePod() error = %v", err)
+	}
+	c.ForgetPod(pod)
+
+	if c.IsAssumed(pod) || c.IsAssigned(pod) {
+		t.Errorf("IsAssumed() = %v, IsAssigned() = %v, want false, false", c.IsAssumed(pod), c.IsAssigned(pod))
+	}
+	if got := c.PodsOnNode("node-1"); len(got) != 0 {
+		t.Errorf("PodsOnNode() = %v, want none", podNames(got))
+	}
+	if !reflect.DeepEqual(events, []string{AssignedPodDeleteEvent}) {
+		t.Errorf("events = %v, want [%s]", events, AssignedPodDeleteEvent)
+	}
+
+	//가정하지 않은 파드는 무시
+	c.ForgetPod(pod)
+	if len(events) != 1 {
+		t.Errorf("events after forgetting again = %v, want one", events)
+	}
+}
+
+func TestSchedulerCacheAssumedPodExpires(t *testing.T) {
+	var events []string
+	c := newTestCache(&events)
+	pod := st.MakePod().Name("expire").UID("expire").Obj()
+	Ledger.Assign(pod, "node-1", []string{"gpu-0"})
+	defer Ledger.Release(pod)
+
+	if err := c.AssumePod(pod, "node-1"); err != nil {
+		t.Fatalf("AssumePod() error = %v", err)
+	}
+	//바인딩이 끝나기 전에는 만료되지 않음
+	c.cleanupAssumedPods(time.Now().Add(2 * assumedPodTTL))
+	if !c.IsAssumed(pod) {
+		t.Fatalf("assumed pod expired before its binding finished")
+	}
+
+	c.FinishBinding(pod)
+	c.cleanupAssumedPods(time.Now())
+	if !c.IsAssumed(pod) {
+		t.Fatalf("assumed pod expired before assumedPodTTL")
+	}
+
+	c.cleanupAssumedPods(time.Now().Add(assumedPodTTL + time.Second))
+	if c.IsAssumed(pod) || c.IsAssigned(pod) {
+		t.Errorf("IsAssumed() = %v, IsAssigned() = %v after expiry, want false, false", c.IsAssumed(pod), c.IsAssigned(pod))
+	}
+	//바인딩은 성공했으므로 informer 이벤트 전까지 GPU 할당 유지
+	if _, ok := Ledger.Assignment(pod.UID); !ok {
+		t.Errorf("expired pod released its GPUs")
+	}
+	if !reflect.DeepEqual(events, []string{AssignedPodDeleteEvent}) {
+		t.Errorf("events = %v, want [%s]", events, AssignedPodDeleteEvent)
+	}
+}
+
+func TestSchedulerCacheAddAssumedPod(t *testing.T) {
+	tests := []struct {
+		name        string
+		nodeName    string //node of the pod reported by the informer
+		wantAssumed bool
+		wantNode    string
+	}{
+		{
+			name:        "update before the binding keeps the assumed node",
+			wantAssumed: true,
+			wantNode:    "node-1",
+		},
+		{
+			name:     "bound pod confirms the assumption",
+			nodeName: "node-1",
+			wantNode: "node-1",
+		},
+		{
+			name:     "pod bound elsewhere moves to that node",
+			nodeName: "node-2",
+			wantNode: "node-2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []string
+			c := newTestCache(&events)
+			pod := st.MakePod().Name("a").UID("a").Obj()
+			if err := c.AssumePod(pod, "node-1"); err != nil {
+				t.Fatalf("AssumePod() error = %v", err)
+			}
+
+			c.addPod(st.MakePod().Name("a").UID("a").Node(tt.nodeName).Phase(corev1.PodPending).Obj())
+
+			if got := c.IsAssumed(pod); got != tt.wantAssumed {
+				t.Errorf("IsAssumed() = %v, want %v", got, tt.wantAssumed)
+			}
+			if got := podNames(c.PodsOnNode(tt.wantNode)); !reflect.DeepEqual(got, []string{"a"}) {
+				t.Errorf("PodsOnNode(%s) = %v, want [a]", tt.wantNode, got)
+			}
+			//이미 가정된 파드는 새로 할당된 파드가 아님
+			if len(events) != 0 {
+				t.Errorf("events = %v, want none", events)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package resourceinfo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"gpu-scheduler/config"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	MPSGPUResource    corev1.ResourceName = "keti.com/mpsgpu"
+	NvidiaGPUResource corev1.ResourceName = "nvidia.com/gpu"
+	UUIDAnnotation                        = "UUID"
+)
+
+var Allocator = NewGPUAllocator(Ledger)
+
+// GPUDevice is a single physical GPU on a node.
+type GPUDevice struct {
+	UUID        string `json:"uuid"`
+	Index       int    `json:"index"`
+	MemoryTotal int64  `json:"memoryTotal"`
+	MPSSlots    int    `json:"mpsSlots"`
+	MPSClients  int    `json:"mpsClients"`
+}
+
+func (d *GPUDevice) FreeSlots() int {
+	return d.MPSSlots - d.MPSClients
+}
+
+// GPUAllocator keeps every GPU of every node and picks free devices for pods.
+type GPUAllocator struct {
+	mu     sync.Mutex
+	nodes  map[string][]*GPUDevice
+	ledger *GPULedger
+}
+
+func NewGPUAllocator(ledger *GPULedger) *GPUAllocator {
+	return &GPUAllocator{
+		nodes:  make(map[string][]*GPUDevice),
+		ledger: ledger,
+	}
+}
+
+// UpdateNode replaces the devices of a node with the GPUs reported in its metric.
+func (a *GPUAllocator) UpdateNode(nodeName string, metric *NodeMetric) {
+	devices := make([]*GPUDevice, 0, len(metric.UUIDs))
+	for i, uuid := range metric.UUIDs {
+		device := &GPUDevice{
+			UUID:     uuid,
+			Index:    i,
+			MPSSlots: config.MPSClientsPerGPU,
+		}
+		if gpuMetric, ok := metric.GPUs[uuid]; ok {
+			device.MemoryTotal = gpuMetric.MemoryTotal
+		}
+		devices = append(devices, device)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nodes[nodeName] = devices
+}
+
+// Devices returns a copy of the devices known on the node with their
+// current number of MPS clients taken from the ledger.
+func (a *GPUAllocator) Devices(nodeName string) []GPUDevice {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	devices := make([]GPUDevice, 0, len(a.nodes[nodeName]))
+	for _, device := range a.nodes[nodeName] {
+		d := *device
+		d.MPSClients = a.ledger.Clients(nodeName, d.UUID)
+		devices = append(devices, d)
+	}
+	return devices
+}
+
+// Allocate picks count distinct devices with a free MPS slot on the node,
+// preferring the least shared ones, and records them for the pod in the ledger.
+func (a *GPUAllocator) Allocate(pod *corev1.Pod, nodeName string, count int) ([]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	free := make([]GPUDevice, 0)
+	for _, device := range a.nodes[nodeName] {
+		d := *device
+		d.MPSClients = a.ledger.Clients(nodeName, d.UUID)
+		if d.FreeSlots() > 0 {
+			free = append(free, d)
+		}
+	}
+	if len(free) < count {
+		return nil, fmt.Errorf("node %s has %d free GPUs, %d requested", nodeName, len(free), count)
+	}
+
+	sort.SliceStable(free, func(i, j int) bool {
+		if free[i].MPSClients != free[j].MPSClients {
+			return free[i].MPSClients < free[j].MPSClients
+		}
+		return free[i].Index < free[j].Index
+	})
+
+	uuids := make([]string, 0, count)
+	for _, device := range free[:count] {
+		uuids = append(uuids, device.UUID)
+	}
+	a.ledger.Assign(pod, nodeName, uuids)
+	return uuids, nil
+}
+
+// FreeGPUs returns the number of devices on the node with at least one free
+// MPS slot and the total number of free MPS slots.
+func (a *GPUAllocator) FreeGPUs(nodeName string) (int, int) {
+	freeDevices, freeSlots := 0, 0
+	for _, device := range a.Devices(nodeName) {
+		if free := device.FreeSlots(); free > 0 {
+			freeDevices++
+			freeSlots += free
+		}
+	}
+	return freeDevices, freeSlots
+}
+
+// GPURequest returns the number of MPS GPUs requested by all containers of the pod.
+func GPURequest(pod *corev1.Pod) int64 {
+	return ExtendedResourceRequest(pod, MPSGPUResource)
+}
+
+// ExtendedResourceRequest returns the amount of an extended resource the pod
+// needs: the sum of its containers or the largest init container. Extended
+// resources may be given only as a limit, which then is the request.
+func ExtendedResourceRequest(pod *corev1.Pod, name corev1.ResourceName) int64 {
+	var total int64
+	for _, container := range pod.Spec.Containers {
+		total += containerExtendedRequest(container, name)
+	}
+	for _, container := range pod.Spec.InitContainers {
+		if req := containerExtendedRequest(container, name); req > total {
+			total = req
+		}
+	}
+	return total
+}
+
+func containerExtendedRequest(container corev1.Container, name corev1.ResourceName) int64 {
+	if req, ok := container.Resources.Requests[name]; ok {
+		return req.Value()
+	}
+	if req, ok := container.Resources.Limits[name]; ok {
+		return req.Value()
+	}
+	return 0
+}
+
+// PodGPUUUIDs returns the GPU UUIDs written to the pod annotation by Binding.
+func PodGPUUUIDs(pod *corev1.Pod) []string {
+	return SplitUUIDs(pod.Annotations[UUIDAnnotation])
+}
+
+func SplitUUIDs(s string) []string {
+	uuids := make([]string, 0)
+	for _, uuid := range strings.Split(s, ",") {
+		if uuid = strings.TrimSpace(uuid); uuid != "" {
+			uuids = append(uuids, uuid)
+		}
+	}
+	return uuids
+}
@@ -0,0 +1,107 @@
+package resourceinfo
+
+import (
+	"reflect"
+	"testing"
+
+	"gpu-scheduler/config"
+	st "gpu-scheduler/testing"
+)
+
+func newTestAllocator(uuids ...string) *GPUAllocator {
+	a := NewGPUAllocator(NewGPULedger())
+	a.UpdateNode("node-1", &NodeMetric{NodeName: "node-1", UUIDs: uuids})
+	return a
+}
+
+func TestGPUAllocatorAllocate(t *testing.T) {
+	defer func(slots int) { config.MPSClientsPerGPU = slots }(config.MPSClientsPerGPU)
+	config.MPSClientsPerGPU = 2
+
+	tests := []struct {
+		name     string
+		existing map[string][]string //pod -> UUIDs already held
+		count    int
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:  "lowest index on an idle node",
+			count: 1,
+			want:  []string{"gpu-0"},
+		},
+		{
+			name:     "least shared GPU first",
+			existing: map[string][]string{"a": {"gpu-0"}},
+			count:    1,
+			want:     []string{"gpu-1"},
+		},
+		{
+			name:     "distinct GPUs for a multi-GPU pod",
+			existing: map[string][]string{"a": {"gpu-1"}},
+			count:    2,
+			want:     []string{"gpu-0", "gpu-1"},
+		},
+		{
+			name:     "full GPU is skipped",
+			existing: map[string][]string{"a": {"gpu-0"}, "b": {"gpu-0"}, "c": {"gpu-1"}},
+			count:    1,
+			want:     []string{"gpu-1"},
+		},
+		{
+			name:     "not enough GPUs with a free slot",
+			existing: map[string][]string{"a": {"gpu-0"}, "b": {"gpu-0"}},
+			count:    2,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAllocator("gpu-0", "gpu-1")
+			for name, uuids := range tt.existing {
+				a.ledger.Assign(st.MakePod().Name(name).UID(name).Obj(), "node-1", uuids)
+			}
+
+			pod := st.MakePod().Name("new").UID("new").Obj()
+			got, err := a.Allocate(pod, "node-1", tt.count)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Allocate() = %v, want error", got)
+				}
+				if _, ok := a.ledger.Assignment(pod.UID); ok {
+					t.Errorf("failed Allocate() recorded the pod in the ledger")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Allocate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allocate() = %v, want %v", got, tt.want)
+			}
+			if assignment, _ := a.ledger.Assignment(pod.UID); !reflect.DeepEqual(assignment.UUIDs, tt.want) {
+				t.Errorf("ledger assignment = %v, want %v", assignment.UUIDs, tt.want)
+			}
+		})
+	}
+}
+
+func TestGPUAllocatorFreeGPUs(t *testing.T) {
+	defer func(slots int) { config.MPSClientsPerGPU = slots }(config.MPSClientsPerGPU)
+	config.MPSClientsPerGPU = 2
+
+	a := newTestAllocator("gpu-0", "gpu-1", "gpu-2")
+	a.ledger.Assign(st.MakePod().Name("a").UID("a").Obj(), "node-1", []string{"gpu-0", "gpu-1"})
+	a.ledger.Assign(st.MakePod().Name("b").UID("b").Obj(), "node-1", []string{"gpu-0"})
+
+	//gpu-0 가득 참, gpu-1 1슬롯, gpu-2 2슬롯
+	devices, slots := a.FreeGPUs("node-1")
+	if devices != 2 || slots != 3 {
+		t.Errorf("FreeGPUs() = %d, %d, want 2, 3", devices, slots)
+	}
+
+	a.ledger.Release(st.MakePod().Name("b").UID("b").Obj())
+	if devices, slots := a.FreeGPUs("node-1"); devices != 3 || slots != 4 {
+		t.Errorf("FreeGPUs() after release = %d, %d, want 3, 4", devices, slots)
+	}
+}
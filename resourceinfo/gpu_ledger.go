@@ -0,0 +1,184 @@
+package resourceinfo
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var Ledger = NewGPULedger()
+
+// GPUAssignment is the set of GPUs held by one pod.
+type GPUAssignment struct {
+	PodKey     string    `json:"pod"`
+	NodeName   string    `json:"node"`
+	UUIDs      []string  `json:"uuids"`
+	AssignedAt time.Time `json:"assignedAt"`
+}
+
+// GPULedger records which pods hold which GPU on which node.
+type GPULedger struct {
+	mu    sync.RWMutex
+	nodes map[string]map[string]map[types.UID]string //node -> GPU UUID -> pod UID -> namespace/name
+	pods  map[types.UID]*GPUAssignment
+}
+
+func NewGPULedger() *GPULedger {
+	return &GPULedger{
+		nodes: make(map[string]map[string]map[types.UID]string),
+		pods:  make(map[types.UID]*GPUAssignment),
+	}
+}
+
+func PodKey(pod *corev1.Pod) string {
+	return pod.Namespace + "/" + pod.Name
+}
+
+// IsTerminated reports whether the pod no longer holds its devices.
+func IsTerminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
+
+// Assign records the pod as a client of the given GPUs. Assigning a pod
+// that is already in the ledger replaces its previous assignment.
+func (l *GPULedger) Assign(pod *corev1.Pod, nodeName string, uuids []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.assign(pod.UID, PodKey(pod), nodeName, uuids)
+}
+
+func (l *GPULedger) assign(uid types.UID, podKey string, nodeName string, uuids []string) {
+	l.release(uid)
+	if len(uuids) == 0 {
+		return
+	}
+
+	gpus, ok := l.nodes[nodeName]
+	if !ok {
+		gpus = make(map[string]map[types.UID]string)
+		l.nodes[nodeName] = gpus
+	}
+	for _, uuid := range uuids {
+		if _, ok := gpus[uuid]; !ok {
+			gpus[uuid] = make(map[types.UID]string)
+		}
+		gpus[uuid][uid] = podKey
+	}
+
+	l.pods[uid] = &GPUAssignment{
+		PodKey:     podKey,
+		NodeName:   nodeName,
+		UUIDs:      uuids,
+		AssignedAt: time.Now(),
+	}
+}
+
+// Release frees the GPUs held by the pod.
+func (l *GPULedger) Release(pod *corev1.Pod) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.release(pod.UID)
+}
+
+func (l *GPULedger) release(uid types.UID) {
+	assignment, ok := l.pods[uid]
+	if !ok {
+		return
+	}
+	gpus := l.nodes[assignment.NodeName]
+	for _, uuid := range assignment.UUIDs {
+		delete(gpus[uuid], uid)
+		if len(gpus[uuid]) == 0 {
+			delete(gpus, uuid)
+		}
+	}
+	if len(gpus) == 0 {
+		delete(l.nodes, assignment.NodeName)
+	}
+	delete(l.pods, uid)
+}
+
+// Observe updates the ledger from the current state of a bound pod.
+func (l *GPULedger) Observe(pod *corev1.Pod) {
+	if pod.Spec.NodeName == "" {
+		return
+	}
+	if IsTerminated(pod) {
+		l.Release(pod)
+		return
+	}
+	uuids := PodGPUUUIDs(pod)
+	if len(uuids) == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if assignment, ok := l.pods[pod.UID]; ok && assignment.NodeName == pod.Spec.NodeName && sameUUIDs(assignment.UUIDs, uuids) {
+		return
+	}
+	l.assign(pod.UID, PodKey(pod), pod.Spec.NodeName, uuids)
+}
+
+// Clients returns the number of pods sharing the GPU.
+func (l *GPULedger) Clients(nodeName string, uuid string) int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.nodes[nodeName][uuid])
+}
+
+// PodsOnGPU returns namespace/name of the pods sharing the GPU.
+func (l *GPULedger) PodsOnGPU(nodeName string, uuid string) []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	pods := make([]string, 0, len(l.nodes[nodeName][uuid]))
+	for _, podKey := range l.nodes[nodeName][uuid] {
+		pods = append(pods, podKey)
+	}
+	return pods
+}
+
+// Assignment returns the GPUs held by the pod.
+func (l *GPULedger) Assignment(uid types.UID) (GPUAssignment, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	assignment, ok := l.pods[uid]
+	if !ok {
+		return GPUAssignment{}, false
+	}
+	return *assignment, true
+}
+
+func sameUUIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Assignments returns every assignment sorted by pod key.
+func (l *GPULedger) Assignments() []GPUAssignment {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	assignments := make([]GPUAssignment, 0, len(l.pods))
+	for _, assignment := range l.pods {
+		a := *assignment
+		a.UUIDs = append([]string(nil), assignment.UUIDs...)
+		assignments = append(assignments, a)
+	}
+	sort.Slice(assignments, func(i, j int) bool {
+		return assignments[i].PodKey < assignments[j].PodKey
+	})
+	return assignments
+}
@@ -0,0 +1,132 @@
+package resourceinfo
+
+import (
+	"reflect"
+	"testing"
+
+	st "gpu-scheduler/testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGPULedgerAssignRelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		assign  map[string][]string //pod -> UUIDs on node-1
+		release []string
+		clients map[string]int //UUID -> clients
+	}{
+		{
+			name:    "pods share a GPU",
+			assign:  map[string][]string{"a": {"gpu-0"}, "b": {"gpu-0", "gpu-1"}},
+			clients: map[string]int{"gpu-0": 2, "gpu-1": 1},
+		},
+		{
+			name:    "release frees only the pod's GPUs",
+			assign:  map[string][]string{"a": {"gpu-0"}, "b": {"gpu-0", "gpu-1"}},
+			release: []string{"b"},
+			clients: map[string]int{"gpu-0": 1, "gpu-1": 0},
+		},
+		{
+			name:    "releasing an unknown pod is a no-op",
+			assign:  map[string][]string{"a": {"gpu-0"}},
+			release: []string{"c"},
+			clients: map[string]int{"gpu-0": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewGPULedger()
+			for name, uuids := range tt.assign {
+				l.Assign(st.MakePod().Name(name).UID(name).Obj(), "node-1", uuids)
+			}
+			for _, name := range tt.release {
+				l.Release(st.MakePod().Name(name).UID(name).Obj())
+			}
+			for uuid, want := range tt.clients {
+				if got := l.Clients("node-1", uuid); got != want {
+					t.Errorf("Clients(%s) = %d, want %d", uuid, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGPULedgerAssignReplaces(t *testing.T) {
+	l := NewGPULedger()
+	pod := st.MakePod().Name("a").UID("a").Obj()
+	l.Assign(pod, "node-1", []string{"gpu-0"})
+	l.Assign(pod, "node-1", []string{"gpu-1"})
+
+	if got := l.Clients("node-1", "gpu-0"); got != 0 {
+		t.Errorf("Clients(gpu-0) = %d, want 0", got)
+	}
+	assignment, ok := l.Assignment(pod.UID)
+	if !ok || !reflect.DeepEqual(assignment.UUIDs, []string{"gpu-1"}) {
+		t.Errorf("Assignment() = %v, %v, want [gpu-1]", assignment.UUIDs, ok)
+	}
+}
+
+func TestGPULedgerObserve(t *testing.T) {
+	running := func() *st.PodWrapper {
+		return st.MakePod().Name("a").UID("a").Node("node-1").Phase(corev1.PodRunning)
+	}
+	tests := []struct {
+		name      string
+		assigned  []string //UUIDs assigned to pod a on node-1 before Observe
+		pod       *corev1.Pod
+		wantUUIDs []string //nil if the pod holds no GPU after Observe
+	}{
+		{
+			name:      "annotated running pod is restored",
+			pod:       running().Annotation(UUIDAnnotation, "gpu-0,gpu-1").Obj(),
+			wantUUIDs: []string{"gpu-0", "gpu-1"},
+		},
+		{
+			name:      "annotation replaces a different assignment",
+			assigned:  []string{"gpu-0"},
+			pod:       running().Annotation(UUIDAnnotation, "gpu-1").Obj(),
+			wantUUIDs: []string{"gpu-1"},
+		},
+		{
+			name:     "terminated pod releases its GPUs",
+			assigned: []string{"gpu-0"},
+			pod:      running().Annotation(UUIDAnnotation, "gpu-0").Phase(corev1.PodSucceeded).Obj(),
+		},
+		{
+			name: "unbound pod is ignored",
+			pod:  st.MakePod().Name("a").UID("a").Annotation(UUIDAnnotation, "gpu-0").Phase(corev1.PodPending).Obj(),
+		},
+		{
+			name:      "pod without annotation keeps a reserved assignment",
+			assigned:  []string{"gpu-0"},
+			pod:       running().Obj(),
+			wantUUIDs: []string{"gpu-0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewGPULedger()
+			if tt.assigned != nil {
+				l.Assign(tt.pod, "node-1", tt.assigned)
+			}
+			l.Observe(tt.pod)
+
+			assignment, ok := l.Assignment(tt.pod.UID)
+			if tt.wantUUIDs == nil {
+				if ok {
+					t.Errorf("Assignment() = %v, want none", assignment.UUIDs)
+				}
+				return
+			}
+			if !ok || !reflect.DeepEqual(assignment.UUIDs, tt.wantUUIDs) {
+				t.Errorf("Assignment() = %v, %v, want %v", assignment.UUIDs, ok, tt.wantUUIDs)
+			}
+			for _, uuid := range tt.wantUUIDs {
+				if got := l.Clients("node-1", uuid); got != 1 {
+					t.Errorf("Clients(%s) = %d, want 1", uuid, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package resourceinfo
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	nodeGPUsDesc = prometheus.NewDesc("gpu_scheduler_node_gpus",
+		"Number of GPUs on the node.", []string{"node"}, nil)
+	nodeAllocatedGPUsDesc = prometheus.NewDesc("gpu_scheduler_node_allocated_gpus",
+		"Number of GPUs on the node with at least one pod assigned.", []string{"node"}, nil)
+	nodeMPSSlotsDesc = prometheus.NewDesc("gpu_scheduler_node_mps_slots",
+		"Number of MPS slots of all GPUs on the node.", []string{"node"}, nil)
+	nodeAllocatedMPSSlotsDesc = prometheus.NewDesc("gpu_scheduler_node_allocated_mps_slots",
+		"Number of MPS slots on the node used by assigned pods.", []string{"node"}, nil)
+)
+
+// GPUAllocationCollector exports the GPU allocation of every node known to
+// the allocator at scrape time.
+type GPUAllocationCollector struct {
+	allocator *GPUAllocator
+}
+
+func NewGPUAllocationCollector(allocator *GPUAllocator) *GPUAllocationCollector {
+	return &GPUAllocationCollector{allocator: allocator}
+}
+
+func (c *GPUAllocationCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- nodeGPUsDesc
+	ch <- nodeAllocatedGPUsDesc
+	ch <- nodeMPSSlotsDesc
+	ch <- nodeAllocatedMPSSlotsDesc
+}
+
+func (c *GPUAllocationCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, nodeName := range c.allocator.NodeNames() {
+		allocatedGPUs, slots, allocatedSlots := 0, 0, 0
+		devices := c.allocator.Devices(nodeName)
+		for _, device := range devices {
+			if device.MPSClients > 0 {
+				allocatedGPUs++
+			}
+			slots += device.MPSSlots
+			allocatedSlots += device.MPSClients
+		}
+		ch <- prometheus.MustNewConstMetric(nodeGPUsDesc, prometheus.GaugeValue, float64(len(devices)), nodeName)
+		ch <- prometheus.MustNewConstMetric(nodeAllocatedGPUsDesc, prometheus.GaugeValue, float64(allocatedGPUs), nodeName)
+		ch <- prometheus.MustNewConstMetric(nodeMPSSlotsDesc, prometheus.GaugeValue, float64(slots), nodeName)
+		ch <- prometheus.MustNewConstMetric(nodeAllocatedMPSSlotsDesc, prometheus.GaugeValue, float64(allocatedSlots), nodeName)
+	}
+}
+
+// NodeNames returns the nodes whose GPUs are known, sorted by name.
+func (a *GPUAllocator) NodeNames() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	names := make([]string, 0, len(a.nodes))
+	for name := range a.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,151 @@
+package resourceinfo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	client "github.com/influxdata/influxdb1-client/v2"
+)
+
+const (
+	nodeMetricMeasurement = "metric"
+	gpuMetricMeasurement  = "gpumetric"
+)
+
+// InfluxDBProvider reads the latest node and GPU metrics written to InfluxDB
+// v1 by the metric collector running on each GPU node.
+type InfluxDBProvider struct {
+	client   client.Client
+	database string
+}
+
+func NewInfluxDBProvider(url string, database string) (*InfluxDBProvider, error) {
+	c, err := client.NewHTTPClient(client.HTTPConfig{
+		Addr:    url,
+		Timeout: metricsQueryTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &InfluxDBProvider{client: c, database: database}, nil
+}
+
+func (m *InfluxDBProvider) Close() error {
+	return m.client.Close()
+}
+
+// NodeMetric returns the node metric with one GPUMetric per GPU UUID.
+func (m *InfluxDBProvider) NodeMetric(ctx context.Context, nodeName string) (*NodeMetric, error) {
+	nodeMetric := NewNodeMetric(nodeName)
+
+	rows, err := m.query(ctx, fmt.Sprintf("SELECT last(*) FROM %s WHERE NodeName='%s'", nodeMetricMeasurement, nodeName))
+	if err != nil {
+		return nodeMetric, err
+	}
+	if len(rows) > 0 {
+		for column, value := range rows[0].values {
+			switch column {
+			case "NodeCPU":
+				nodeMetric.NodeCPU = toFloat(value)
+			case "NodeMemory":
+				nodeMetric.NodeMemory = toFloat(value)
+			case "GPUCount":
+				nodeMetric.GPUCount = int(toFloat(value))
+			case "UUID":
+				nodeMetric.UUIDs = SplitUUIDs(fmt.Sprint(value))
+			}
+		}
+	}
+	for i, uuid := range nodeMetric.UUIDs {
+		nodeMetric.GPUs[uuid] = &GPUMetric{UUID: uuid, Index: i}
+	}
+
+	//GPU별 메트릭은 UUID 태그로 구분
+	rows, err = m.query(ctx, fmt.Sprintf("SELECT last(*) FROM %s WHERE NodeName='%s' GROUP BY \"UUID\"", gpuMetricMeasurement, nodeName))
+	if err != nil {
+		return nodeMetric, err
+	}
+	for _, row := range rows {
+		gpuMetric, ok := nodeMetric.GPUs[row.tags["UUID"]]
+		if !ok {
+			continue
+		}
+		for column, value := range row.values {
+			switch column {
+			case "GPUUtil":
+				gpuMetric.Utilization = toFloat(value)
+			case "GPUMemoryUsed":
+				gpuMetric.MemoryUsed = int64(toFloat(value))
+			case "GPUMemoryTotal":
+				gpuMetric.MemoryTotal = int64(toFloat(value))
+			case "GPUTemperature":
+				gpuMetric.Temperature = toFloat(value)
+			case "GPUPower":
+				gpuMetric.Power = toFloat(value)
+			case "MPSCount":
+				gpuMetric.MPSClients = int(toFloat(value))
+			}
+		}
+	}
+
+	return nodeMetric, nil
+}
+
+type metricRow struct {
+	tags   map[string]string
+	values map[string]interface{}
+}
+
+// query runs the statement and returns the first value of every series,
+// keyed by column name without the last_ prefix of last(*). The client has no
+// context support, so the query is left to its timeout when ctx is done.
+func (m *InfluxDBProvider) query(ctx context.Context, command string) ([]metricRow, error) {
+	type queryResult struct {
+		response *client.Response
+		err      error
+	}
+	resultCh := make(chan queryResult, 1)
+	go func() {
+		response, err := m.client.Query(client.Query{
+			Command:  command,
+			Database: m.database,
+		})
+		resultCh <- queryResult{response: response, err: err}
+	}()
+
+	var response *client.Response
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-resultCh:
+		response = result.response
+		err := result.err
+		if err == nil {
+			err = response.Error()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rows := make([]metricRow, 0)
+	for _, result := range response.Results {
+		for _, series := range result.Series {
+			if len(series.Values) == 0 {
+				continue
+			}
+			row := metricRow{
+				tags:   series.Tags,
+				values: make(map[string]interface{}),
+			}
+			for i, column := range series.Columns {
+				if i < len(series.Values[0]) && series.Values[0][i] != nil {
+					row.values[strings.TrimPrefix(column, "last_")] = series.Values[0][i]
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,156 @@
+package resourceinfo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestInfluxDB serves the responses keyed by measurement to the queries
+// of the provider.
+func newTestInfluxDB(t *testing.T, responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("q")
+		for measurement, response := range responses {
+			if strings.Contains(query, " FROM "+measurement+" ") {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, response)
+				return
+			}
+		}
+		t.Errorf("unexpected query %q", query)
+		http.Error(w, "unexpected query", http.StatusBadRequest)
+	}))
+}
+
+func TestInfluxDBProviderNodeMetric(t *testing.T) {
+	nodeSeries := `{"results":[{"statement_id":0,"series":[{"name":"metric",
+		"columns":["time","last_NodeCPU","last_NodeMemory","last_GPUCount","last_UUID"],
+		"values":[["2021-08-01T00:00:00Z",12.5,"40",2,"GPU-a,GPU-b"]]}]}]}`
+	gpuSeries := `{"results":[{"statement_id":0,"series":[
+		{"name":"gpumetric","tags":{"UUID":"GPU-a"},
+		 "columns":["time","last_GPUUtil","last_GPUMemoryUsed","last_GPUMemoryTotal","last_GPUTemperature","last_GPUPower","last_MPSCount"],
+		 "values":[["2021-08-01T00:00:00Z",30,2048,16160,45,70.5,1]]},
+		{"name":"gpumetric","tags":{"UUID":"GPU-b"},
+		 "columns":["time","last_GPUUtil","last_GPUMemoryUsed"],
+		 "values":[["2021-08-01T00:00:00Z",90,null]]},
+		{"name":"gpumetric","tags":{"UUID":"GPU-removed"},
+		 "columns":["time","last_GPUUtil"],
+		 "values":[["2021-08-01T00:00:00Z",10]]}]}]}`
+	emptySeries := `{"results":[{"statement_id":0}]}`
+
+	tests := []struct {
+		name      string
+		responses map[string]string
+		want      *NodeMetric
+		wantErr   bool
+	}{
+		{
+			name:      "node and GPU series",
+			responses: map[string]string{nodeMetricMeasurement: nodeSeries, gpuMetricMeasurement: gpuSeries},
+			want: &NodeMetric{
+				NodeName:   "node-1",
+				NodeCPU:    12.5,
+				NodeMemory: 40,
+				GPUCount:   2,
+				UUIDs:      []string{"GPU-a", "GPU-b"},
+				GPUs: map[string]*GPUMetric{
+					"GPU-a": {UUID: "GPU-a", Index: 0, Utilization: 30, MemoryUsed: 2048, MemoryTotal: 16160, Temperature: 45, Power: 70.5, MPSClients: 1},
+					"GPU-b": {UUID: "GPU-b", Index: 1, Utilization: 90},
+				},
+			},
+		},
+		{
+			name:      "GPUs without a GPU series",
+			responses: map[string]string{nodeMetricMeasurement: nodeSeries, gpuMetricMeasurement: emptySeries},
+			want: &NodeMetric{
+				NodeName:   "node-1",
+				NodeCPU:    12.5,
+				NodeMemory: 40,
+				GPUCount:   2,
+				UUIDs:      []string{"GPU-a", "GPU-b"},
+				GPUs: map[string]*GPUMetric{
+					"GPU-a": {UUID: "GPU-a", Index: 0},
+					"GPU-b": {UUID: "GPU-b", Index: 1},
+				},
+			},
+		},
+		{
+			name:      "node without series",
+			responses: map[string]string{nodeMetricMeasurement: emptySeries, gpuMetricMeasurement: emptySeries},
+			want:      &NodeMetric{NodeName: "node-1", UUIDs: []string{}, GPUs: map[string]*GPUMetric{}},
+		},
+		{
+			name:      "query error",
+			responses: map[string]string{nodeMetricMeasurement: `{"results":[{"statement_id":0,"error":"database not found: multimetric"}]}`},
+			wantErr:   true,
+		},
+		{
+			name:      "GPU query error",
+			responses: map[string]string{nodeMetricMeasurement: nodeSeries, gpuMetricMeasurement: `{"error":"timeout"}`},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestInfluxDB(t, tt.responses)
+			defer server.Close()
+			provider, err := NewInfluxDBProvider(server.URL, "multimetric")
+			if err != nil {
+				t.Fatalf("NewInfluxDBProvider() error = %v", err)
+			}
+			defer provider.Close()
+
+			got, err := provider.NodeMetric(context.TODO(), "node-1")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("NodeMetric() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NodeMetric() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NodeMetric() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInfluxDBProviderUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	provider, err := NewInfluxDBProvider(server.URL, "multimetric")
+	if err != nil {
+		t.Fatalf("NewInfluxDBProvider() error = %v", err)
+	}
+	if _, err := provider.NodeMetric(context.TODO(), "node-1"); err == nil {
+		t.Errorf("NodeMetric() from a stopped server succeeded")
+	}
+}
+
+func TestInfluxDBProviderContextDone(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	provider, err := NewInfluxDBProvider(server.URL, "multimetric")
+	if err != nil {
+		t.Fatalf("NewInfluxDBProvider() error = %v", err)
+	}
+	defer provider.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := provider.NodeMetric(ctx, "node-1"); err != context.DeadlineExceeded {
+		t.Errorf("NodeMetric() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,124 @@
+package resourceinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DCGM exporter metric names
+const (
+	dcgmGPUUtil     = "DCGM_FI_DEV_GPU_UTIL"
+	dcgmFBUsed      = "DCGM_FI_DEV_FB_USED"
+	dcgmFBFree      = "DCGM_FI_DEV_FB_FREE"
+	dcgmGPUTemp     = "DCGM_FI_DEV_GPU_TEMP"
+	dcgmPowerUsage  = "DCGM_FI_DEV_POWER_USAGE"
+	dcgmUUIDLabel   = "UUID"
+	dcgmGPUIdxLabel = "gpu"
+)
+
+// PrometheusProvider queries the Prometheus HTTP API for the metrics of the
+// DCGM exporter. Node CPU and memory usage are not reported by DCGM.
+type PrometheusProvider struct {
+	url       string
+	nodeLabel string
+	client    *http.Client
+}
+
+func NewPrometheusProvider(address string, nodeLabel string) (*PrometheusProvider, error) {
+	if address == "" {
+		return nil, fmt.Errorf("prometheus url is empty")
+	}
+	return &PrometheusProvider{
+		url:       strings.TrimSuffix(address, "/"),
+		nodeLabel: nodeLabel,
+		client:    &http.Client{Timeout: metricsQueryTimeout},
+	}, nil
+}
+
+func (p *PrometheusProvider) Close() error {
+	return nil
+}
+
+type prometheusResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+func (p *PrometheusProvider) NodeMetric(ctx context.Context, nodeName string) (*NodeMetric, error) {
+	nodeMetric := NewNodeMetric(nodeName)
+
+	names := strings.Join([]string{dcgmGPUUtil, dcgmFBUsed, dcgmFBFree, dcgmGPUTemp, dcgmPowerUsage}, "|")
+	query := fmt.Sprintf(`{__name__=~"%s",%s="%s"}`, names, p.nodeLabel, nodeName)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url+"/api/v1/query?query="+url.QueryEscape(query), nil)
+	if err != nil {
+		return nodeMetric, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nodeMetric, err
+	}
+	defer resp.Body.Close()
+
+	var result prometheusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nodeMetric, fmt.Errorf("failed to decode prometheus response: %v", err)
+	}
+	if result.Status != "success" {
+		return nodeMetric, fmt.Errorf("prometheus query failed: %s", result.Error)
+	}
+
+	for _, sample := range result.Data.Result {
+		uuid := sample.Metric[dcgmUUIDLabel]
+		if uuid == "" || len(sample.Value) != 2 {
+			continue
+		}
+		gpuMetric, ok := nodeMetric.GPUs[uuid]
+		if !ok {
+			gpuMetric = &GPUMetric{UUID: uuid, Index: int(toFloat(sample.Metric[dcgmGPUIdxLabel]))}
+			nodeMetric.GPUs[uuid] = gpuMetric
+		}
+
+		value := toFloat(sample.Value[1])
+		switch sample.Metric["__name__"] {
+		case dcgmGPUUtil:
+			gpuMetric.Utilization = value
+		case dcgmFBUsed:
+			gpuMetric.MemoryUsed = int64(value)
+			gpuMetric.MemoryTotal += int64(value)
+		case dcgmFBFree:
+			gpuMetric.MemoryTotal += int64(value)
+		case dcgmGPUTemp:
+			gpuMetric.Temperature = value
+		case dcgmPowerUsage:
+			gpuMetric.Power = value
+		}
+	}
+
+	//GPU 인덱스 순으로 UUID 목록 구성
+	gpus := make([]*GPUMetric, 0, len(nodeMetric.GPUs))
+	for _, gpuMetric := range nodeMetric.GPUs {
+		gpus = append(gpus, gpuMetric)
+	}
+	sort.Slice(gpus, func(i, j int) bool {
+		return gpus[i].Index < gpus[j].Index
+	})
+	for _, gpuMetric := range gpus {
+		nodeMetric.UUIDs = append(nodeMetric.UUIDs, gpuMetric.UUID)
+	}
+	nodeMetric.GPUCount = len(nodeMetric.UUIDs)
+
+	return nodeMetric, nil
+}
@@ -0,0 +1,29 @@
+package resourceinfo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPrometheusProviderContextDone(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	provider, err := NewPrometheusProvider(server.URL, "Hostname")
+	if err != nil {
+		t.Fatalf("NewPrometheusProvider() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := provider.NodeMetric(ctx, "node-1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("NodeMetric() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
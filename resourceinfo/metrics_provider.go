@@ -0,0 +1,91 @@
+package resourceinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gpu-scheduler/config"
+	"gpu-scheduler/metrics"
+)
+
+// metricsQueryTimeout bounds a query to the metric source, so that a slow
+// source cannot hold a scheduling cycle that is not cancelled.
+const metricsQueryTimeout = 10 * time.Second
+
+// MetricsProvider returns the latest metric of a node and its GPUs. The query
+// is abandoned when ctx is done.
+type MetricsProvider interface {
+	NodeMetric(ctx context.Context, nodeName string) (*NodeMetric, error)
+	Close() error
+}
+
+// NewMetricsProvider builds the provider selected by the metrics source of a profile.
+func NewMetricsProvider(source config.MetricsSource) (MetricsProvider, error) {
+	provider, err := newMetricsProvider(source)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedProvider{name: source.Provider, MetricsProvider: provider}, nil
+}
+
+func newMetricsProvider(source config.MetricsSource) (MetricsProvider, error) {
+	switch source.Provider {
+	case config.InfluxDBMetricsProvider:
+		if source.InfluxDB != nil {
+			return NewInfluxDBProvider(source.InfluxDB.URL, source.InfluxDB.Database)
+		}
+	case config.PrometheusMetricsProvider:
+		if source.Prometheus != nil {
+			return NewPrometheusProvider(source.Prometheus.URL, source.Prometheus.NodeLabel)
+		}
+	case config.StaticMetricsProvider:
+		if source.Static != nil {
+			return NewStaticProvider(source.Static.File)
+		}
+	default:
+		return nil, fmt.Errorf("unknown metrics provider %q, must be one of %s, %s, %s",
+			source.Provider, config.InfluxDBMetricsProvider, config.PrometheusMetricsProvider, config.StaticMetricsProvider)
+	}
+	return nil, fmt.Errorf("metrics provider %s is not configured", source.Provider)
+}
+
+// instrumentedProvider records the latency and the errors of the queries.
+type instrumentedProvider struct {
+	name string
+	MetricsProvider
+}
+
+func (p *instrumentedProvider) NodeMetric(ctx context.Context, nodeName string) (*NodeMetric, error) {
+	start := time.Now()
+	nodeMetric, err := p.MetricsProvider.NodeMetric(ctx, nodeName)
+	metrics.ObserveMetricsQuery(p.name, start, err)
+	return nodeMetric, err
+}
+
+func NewNodeMetric(nodeName string) *NodeMetric {
+	return &NodeMetric{
+		NodeName: nodeName,
+		UUIDs:    make([]string, 0),
+		GPUs:     make(map[string]*GPUMetric),
+	}
+}
+
+func toFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f
+	}
+	return 0
+}
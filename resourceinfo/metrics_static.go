@@ -0,0 +1,129 @@
+package resourceinfo
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// StaticProvider serves metrics from a JSON or YAML file, for tests and
+// clusters without a metrics backend. The file is reloaded when it changes.
+//
+//	nodes:
+//	- nodeName: gpuserver1
+//	  nodeCPU: 12.5
+//	  nodeMemory: 40
+//	  gpus:
+//	  - uuid: GPU-a06cd524-72c4-d6f0-4eda-d64af512dd8b
+//	    utilization: 30
+//	    memoryUsed: 2048
+//	    memoryTotal: 16160
+//	    temperature: 45
+//	    power: 70
+type StaticProvider struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	nodes   map[string]staticNodeMetric
+}
+
+type staticMetricsFile struct {
+	Nodes []staticNodeMetric `json:"nodes"`
+}
+
+type staticNodeMetric struct {
+	NodeName   string            `json:"nodeName"`
+	NodeCPU    float64           `json:"nodeCPU"`
+	NodeMemory float64           `json:"nodeMemory"`
+	GPUs       []staticGPUMetric `json:"gpus"`
+}
+
+type staticGPUMetric struct {
+	UUID        string  `json:"uuid"`
+	Utilization float64 `json:"utilization"`
+	MemoryUsed  int64   `json:"memoryUsed"`
+	MemoryTotal int64   `json:"memoryTotal"`
+	Temperature float64 `json:"temperature"`
+	Power       float64 `json:"power"`
+	MPSClients  int     `json:"mpsClients"`
+}
+
+func NewStaticProvider(path string) (*StaticProvider, error) {
+	p := &StaticProvider{path: path}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *StaticProvider) Close() error {
+	return nil
+}
+
+func (p *StaticProvider) NodeMetric(ctx context.Context, nodeName string) (*NodeMetric, error) {
+	if err := p.reload(); err != nil {
+		return NewNodeMetric(nodeName), err
+	}
+
+	p.mu.Lock()
+	node, ok := p.nodes[nodeName]
+	p.mu.Unlock()
+
+	nodeMetric := NewNodeMetric(nodeName)
+	if !ok {
+		return nodeMetric, nil
+	}
+	nodeMetric.NodeCPU = node.NodeCPU
+	nodeMetric.NodeMemory = node.NodeMemory
+	nodeMetric.GPUCount = len(node.GPUs)
+	for i, gpu := range node.GPUs {
+		nodeMetric.UUIDs = append(nodeMetric.UUIDs, gpu.UUID)
+		nodeMetric.GPUs[gpu.UUID] = &GPUMetric{
+			UUID:        gpu.UUID,
+			Index:       i,
+			Utilization: gpu.Utilization,
+			MemoryUsed:  gpu.MemoryUsed,
+			MemoryTotal: gpu.MemoryTotal,
+			Temperature: gpu.Temperature,
+			Power:       gpu.Power,
+			MPSClients:  gpu.MPSClients,
+		}
+	}
+	return nodeMetric, nil
+}
+
+func (p *StaticProvider) reload() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.nodes != nil && info.ModTime().Equal(p.modTime) {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	var file staticMetricsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse metrics file %s: %v", p.path, err)
+	}
+
+	nodes := make(map[string]staticNodeMetric)
+	for _, node := range file.Nodes {
+		nodes[node.NodeName] = node
+	}
+	p.nodes = nodes
+	p.modTime = info.ModTime()
+	return nil
+}
@@ -0,0 +1,104 @@
+package resourceinfo
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// AffinityTerm is a PodAffinityTerm with its label selector parsed and its
+// namespaces resolved against the pod that declares it.
+type AffinityTerm struct {
+	Namespaces  sets.String
+	Selector    labels.Selector
+	TopologyKey string
+}
+
+// WeightedAffinityTerm is an AffinityTerm of a preferred affinity.
+type WeightedAffinityTerm struct {
+	AffinityTerm
+	Weight int32
+}
+
+// Matches reports whether the pod is in the namespaces of the term and has
+// labels selected by it.
+func (t *AffinityTerm) Matches(pod *corev1.Pod) bool {
+	return t.Namespaces.Has(pod.Namespace) && t.Selector.Matches(labels.Set(pod.Labels))
+}
+
+// newAffinityTerm parses a term of the pod. A term without namespaces
+// selects the namespace of the pod. namespaceSelector is an alpha field
+// disabled by default and is not supported.
+func newAffinityTerm(pod *corev1.Pod, term *corev1.PodAffinityTerm) (*AffinityTerm, error) {
+	namespaces := sets.NewString(term.Namespaces...)
+	if len(namespaces) == 0 {
+		namespaces.Insert(pod.Namespace)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	return &AffinityTerm{Namespaces: namespaces, Selector: selector, TopologyKey: term.TopologyKey}, nil
+}
+
+// GetAffinityTerms parses the required terms of the pod.
+func GetAffinityTerms(pod *corev1.Pod, terms []corev1.PodAffinityTerm) ([]AffinityTerm, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	affinityTerms := make([]AffinityTerm, 0, len(terms))
+	for i := range terms {
+		t, err := newAffinityTerm(pod, &terms[i])
+		if err != nil {
+			return nil, err
+		}
+		affinityTerms = append(affinityTerms, *t)
+	}
+	return affinityTerms, nil
+}
+
+// GetWeightedAffinityTerms parses the preferred terms of the pod.
+func GetWeightedAffinityTerms(pod *corev1.Pod, terms []corev1.WeightedPodAffinityTerm) ([]WeightedAffinityTerm, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	weightedTerms := make([]WeightedAffinityTerm, 0, len(terms))
+	for i := range terms {
+		if terms[i].Weight == 0 {
+			continue
+		}
+		t, err := newAffinityTerm(pod, &terms[i].PodAffinityTerm)
+		if err != nil {
+			return nil, err
+		}
+		weightedTerms = append(weightedTerms, WeightedAffinityTerm{AffinityTerm: *t, Weight: terms[i].Weight})
+	}
+	return weightedTerms, nil
+}
+
+// GetPodAffinityTerms returns the required pod affinity terms of the pod.
+func GetPodAffinityTerms(pod *corev1.Pod) []corev1.PodAffinityTerm {
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.PodAffinity == nil {
+		return nil
+	}
+	return affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+}
+
+// GetPodAntiAffinityTerms returns the required pod anti-affinity terms of
+// the pod.
+func GetPodAntiAffinityTerms(pod *corev1.Pod) []corev1.PodAffinityTerm {
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.PodAntiAffinity == nil {
+		return nil
+	}
+	return affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+}
+
+// HasPodAffinity reports whether the pod has any pod affinity or
+// anti-affinity, required or preferred.
+func HasPodAffinity(pod *corev1.Pod) bool {
+	affinity := pod.Spec.Affinity
+	return affinity != nil && (affinity.PodAffinity != nil || affinity.PodAntiAffinity != nil)
+}
@@ -4,50 +4,141 @@ import (
 	corev1 "k8s.io/api/core/v1"
 )
 
-var AvailableNodeCount *int64 = new(int64)
-
 type NodeInfo struct {
 	// Overall node information.
-	NodeName   string
-	Node       corev1.Node
-	Pods       []*corev1.Pod
-	Affinity   map[string]string
-	NodeScore  float64
-	IsFiltered bool
+	NodeName    string
+	Node        corev1.Node
+	Pods        []*corev1.Pod
+	NodeScore   float64
+	IsFiltered  bool
+	Requested   *Resource
+	Allocatable *Resource
+	Metric      *NodeMetric
+}
+
+// NewNodeInfo returns the node with its pods and the resources they request.
+func NewNodeInfo(node *corev1.Node, pods ...*corev1.Pod) *NodeInfo {
+	return &NodeInfo{
+		NodeName:    node.Name,
+		Node:        *node,
+		Pods:        pods,
+		Requested:   GetNodeRequested(pods),
+		Allocatable: NewResource(node.Status.Allocatable),
+	}
 }
 
 type NodeMetric struct {
 	//Overall node metric information.
 	NodeName   string
-	NodeCPU    string
-	NodeMemory string
+	NodeCPU    float64 //usage (%)
+	NodeMemory float64 //usage (%)
 	GPUCount   int
-	UUID       string
+	UUIDs      []string
+	GPUs       map[string]*GPUMetric //key: GPU UUID
+}
+
+// IsEmpty reports whether the metric source returned nothing for the node,
+// e.g. because its exporter is not running.
+func (m *NodeMetric) IsEmpty() bool {
+	return m.NodeCPU == 0 && m.NodeMemory == 0 && len(m.UUIDs) == 0 && len(m.GPUs) == 0
+}
+
+type GPUMetric struct {
+	//Per-GPU metric information.
+	UUID        string
+	Index       int
+	Utilization float64 //SM utilization (%)
+	MemoryUsed  int64   //MiB
+	MemoryTotal int64   //MiB
+	Temperature float64 //celsius
+	Power       float64 //watt
+	MPSClients  int
+}
+
+// MemoryFree returns the unused memory of the GPU in MiB.
+func (g *GPUMetric) MemoryFree() int64 {
+	if g.MemoryTotal < g.MemoryUsed {
+		return 0
+	}
+	return g.MemoryTotal - g.MemoryUsed
 }
 
 func (n *NodeInfo) FilterNode() error {
 	n.IsFiltered = true
-	*AvailableNodeCount--
 	return nil
 }
 
 // Resource is a collection of compute resource.
 type Resource struct {
-	MilliCPU         int64
-	Memory           int64
-	EphemeralStorage int64
+	MilliCPU         int64 `json:"milliCPU"`
+	Memory           int64 `json:"memory"`
+	EphemeralStorage int64 `json:"ephemeralStorage"`
 }
 
-type PodWatchEvent struct {
-	Type   string     `json:"type"`
-	Object corev1.Pod `json:"object"`
+func NewResource(rl corev1.ResourceList) *Resource {
+	r := &Resource{}
+	r.Add(rl)
+	return r
+}
+
+// Add adds ResourceList into Resource.
+func (r *Resource) Add(rl corev1.ResourceList) {
+	for name, quantity := range rl {
+		switch name {
+		case corev1.ResourceCPU:
+			r.MilliCPU += quantity.MilliValue()
+		case corev1.ResourceMemory:
+			r.Memory += quantity.Value()
+		case corev1.ResourceEphemeralStorage:
+			r.EphemeralStorage += quantity.Value()
+		}
+	}
+}
+
+// SetMaxResource keeps the larger of Resource and ResourceList for each resource.
+func (r *Resource) SetMaxResource(rl corev1.ResourceList) {
+	other := NewResource(rl)
+	if other.MilliCPU > r.MilliCPU {
+		r.MilliCPU = other.MilliCPU
+	}
+	if other.Memory > r.Memory {
+		r.Memory = other.Memory
+	}
+	if other.EphemeralStorage > r.EphemeralStorage {
+		r.EphemeralStorage = other.EphemeralStorage
+	}
+}
+
+// GetPodResourceRequest returns the resources the pod needs on a node: the
+// sum of its containers or the largest init container, whichever is larger,
+// plus the pod overhead.
+func GetPodResourceRequest(pod *corev1.Pod) *Resource {
+	result := &Resource{}
+	for _, container := range pod.Spec.Containers {
+		result.Add(container.Resources.Requests)
+	}
+	for _, container := range pod.Spec.InitContainers {
+		result.SetMaxResource(container.Resources.Requests)
+	}
+	if pod.Spec.Overhead != nil {
+		result.Add(pod.Spec.Overhead)
+	}
+	return result
 }
 
-//return if the node is master or not
-func IsMaster(node corev1.Node) bool {
-	if _, ok := node.Labels["node-role.kubernetes.io/master"]; ok {
-		return true
+// GetNodeRequested sums the resource requests of the pods on a node.
+func GetNodeRequested(pods []*corev1.Pod) *Resource {
+	requested := &Resource{}
+	for _, pod := range pods {
+		podRequest := GetPodResourceRequest(pod)
+		requested.MilliCPU += podRequest.MilliCPU
+		requested.Memory += podRequest.Memory
+		requested.EphemeralStorage += podRequest.EphemeralStorage
 	}
+	return requested
+}
 
-	return false
+type PodWatchEvent struct {
+	Type   string     `json:"type"`
+	Object corev1.Pod `json:"object"`
 }
@@ -0,0 +1,69 @@
+package resourceinfo
+
+import (
+	"reflect"
+	"testing"
+
+	st "gpu-scheduler/testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGetPodResourceRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want Resource
+	}{
+		{
+			name: "containers are summed",
+			pod: st.MakePod().
+				Req(map[corev1.ResourceName]string{corev1.ResourceCPU: "500m", corev1.ResourceMemory: "1Gi"}).
+				Req(map[corev1.ResourceName]string{corev1.ResourceCPU: "1", corev1.ResourceEphemeralStorage: "2Gi"}).
+				Obj(),
+			want: Resource{MilliCPU: 1500, Memory: 1 << 30, EphemeralStorage: 2 << 30},
+		},
+		{
+			name: "init container larger than the sum of the containers",
+			pod: st.MakePod().
+				Req(map[corev1.ResourceName]string{corev1.ResourceCPU: "500m", corev1.ResourceMemory: "1Gi"}).
+				Req(map[corev1.ResourceName]string{corev1.ResourceCPU: "500m", corev1.ResourceMemory: "1Gi"}).
+				InitReq(map[corev1.ResourceName]string{corev1.ResourceCPU: "2", corev1.ResourceMemory: "1Gi"}).
+				Obj(),
+			want: Resource{MilliCPU: 2000, Memory: 2 << 30},
+		},
+		{
+			name: "largest init container counts, not their sum",
+			pod: st.MakePod().
+				Req(map[corev1.ResourceName]string{corev1.ResourceCPU: "100m"}).
+				InitReq(map[corev1.ResourceName]string{corev1.ResourceCPU: "300m"}).
+				InitReq(map[corev1.ResourceName]string{corev1.ResourceCPU: "200m"}).
+				Obj(),
+			want: Resource{MilliCPU: 300},
+		},
+		{
+			name: "overhead is added",
+			pod: st.MakePod().
+				Req(map[corev1.ResourceName]string{corev1.ResourceCPU: "500m", corev1.ResourceMemory: "1Gi"}).
+				Overhead(map[corev1.ResourceName]string{corev1.ResourceCPU: "250m", corev1.ResourceMemory: "128Mi"}).
+				Obj(),
+			want: Resource{MilliCPU: 750, Memory: 1<<30 + 128<<20},
+		},
+		{
+			name: "overhead is added on top of the init container",
+			pod: st.MakePod().
+				Req(map[corev1.ResourceName]string{corev1.ResourceCPU: "500m"}).
+				InitReq(map[corev1.ResourceName]string{corev1.ResourceCPU: "1"}).
+				Overhead(map[corev1.ResourceName]string{corev1.ResourceCPU: "250m"}).
+				Obj(),
+			want: Resource{MilliCPU: 1250},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetPodResourceRequest(tt.pod); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("GetPodResourceRequest() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -16,125 +16,43 @@ package resourceinfo
 import (
 	"context"
 	"fmt"
-	"strings"
+
+	"gpu-scheduler/logging"
 
 	_ "github.com/influxdata/influxdb1-client" // this is important because of the bug in go mod
-	client "github.com/influxdata/influxdb1-client/v2"
-	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 )
 
-func GetNodes() (*corev1.NodeList, error) {
-	host_config, _ := rest.InClusterConfig()
-	host_kubeClient := kubernetes.NewForConfigOrDie(host_config)
-	nodeList, _ := host_kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
-
-	return nodeList, nil
-}
-
-func GetPods() (*corev1.PodList, error) {
-	host_config, _ := rest.InClusterConfig()
-	host_kubeClient := kubernetes.NewForConfigOrDie(host_config)
-	podList, _ := host_kubeClient.CoreV1().Pods(corev1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
-
-	return podList, nil
-}
-
-func NodeUpdate(nodeInfoList []*NodeInfo, nodeMetricList []*NodeMetric) ([]*NodeInfo, []*NodeMetric, error) {
-	host_config, _ := rest.InClusterConfig()
-	host_kubeClient := kubernetes.NewForConfigOrDie(host_config)
-
-	ip := "influxdb.gpu.svc.cluster.local"
-	port := "8086"
-	url := "http://" + ip + ":" + port
-	c, err := client.NewHTTPClient(client.HTTPConfig{
-		Addr: url,
-	})
-	if err != nil {
-		fmt.Println("Error creatring influx", err.Error())
+// NodeUpdate adds the nodes of the cache to nodeInfoList. Node metrics are
+// queried later by UpdateNodeMetric, only for the nodes that are filtered.
+func NodeUpdate(nodeInfoList []*NodeInfo) ([]*NodeInfo, error) {
+	if Cache == nil || !Cache.HasSynced() {
+		return nodeInfoList, fmt.Errorf("node cache is not synced")
 	}
-	defer c.Close()
-
-	pods, _ := host_kubeClient.CoreV1().Pods(corev1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
-	nodes, _ := host_kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
-
-	for _, node := range nodes.Items {
-		if IsMaster(node) {
-			continue
-		}
-
-		*AvailableNodeCount++
-
-		podsInNode := make([]*corev1.Pod, 0)
-
-		for _, pod := range pods.Items {
-			if strings.Compare(pod.Spec.NodeName, node.Name) == 0 {
-				podsInNode = append(podsInNode, &pod)
-			}
-		}
-
-		// Get Affinity
-		node_affinity := make(map[string]string)
-
-		for key, value := range node.Labels {
-			switch key {
-			case "failure-domain.beta.kubernetes.io/region":
-				if _, ok := node_affinity["region"]; !ok {
-					node_affinity["region"] = value
-				}
-
-			case "failure-domain.beta.kubernetes.io/zone":
-				if _, ok := node_affinity["zone"]; !ok {
-					node_affinity["zone"] = value
-				}
-			}
-		}
 
+	//캐시에서 같은 시점의 노드/파드 정보를 한 번에 가져옴
+	for _, snapshot := range Cache.Snapshot() {
 		// make new Node
-		newNodeInfo := &NodeInfo{
-			NodeName:  node.Name,
-			Node:      node,
-			Pods:      podsInNode,
-			Affinity:  node_affinity,
-			NodeScore: 0,
-		}
-		nodeInfoList = append(nodeInfoList, newNodeInfo)
-
-		q := client.Query{
-			Command:  fmt.Sprintf("SELECT last(*) FROM metric where NodeName=%s", node.Name),
-			Database: "multimetric",
-		}
-		aaa := make(map[string]string)
-		if response, err := c.Query(q); err == nil && response.Error() == nil {
-			fmt.Println(response.Results[0].Series[0])
-			aaa = response.Results[0].Series[0].Values
-			NodeCPU := aaa[0]
-			NodeMemory := aaa[1]
-			GPUCount := aaa[2]
-			UUID := aaa[3]
-			var myData [][]interface{} = make([][]interface{}, len(response.Results[0].Series[0].Values))
-			for i, d := range response.Results[0].Series[0].Values {
-				myData[i] = d
-			}
-
-			fmt.Println("", myData[0]) //first element in slice
-			fmt.Println("", myData[0][0])
-			fmt.Println("", myData[0][1])
-
-		}
+		nodeInfoList = append(nodeInfoList, NewNodeInfo(&snapshot.Node, snapshot.Pods...))
+	}
+	return nodeInfoList, nil
+}
 
-		newNodeMetric := &NodeMetric{
-			NodeName:   node.Name,
-			NodeCPU:    aaa[0],
-			NodeMemory: NodeMemory,
-			GPUCount:   GPUCount,
-			UUID:       UUID,
+// UpdateNodeMetric queries the metric of the node and updates its GPU list
+// from it. It is called while the node is filtered, so that the nodes left
+// out when filtering stops early are not queried. The node has no metric if
+// the query failed or ctx was done before it finished.
+func UpdateNodeMetric(ctx context.Context, metrics MetricsProvider, nodeInfo *NodeInfo) {
+	newNodeMetric, err := metrics.NodeMetric(ctx, nodeInfo.NodeName)
+	if err != nil {
+		//조회 실패 시 이전 GPU 목록 유지, 일부만 채워진 메트릭은 점수 계산에 사용하지 않음
+		nodeInfo.Metric = nil
+		//필터링이 일찍 끝나 취소된 조회는 오류가 아님
+		if ctx.Err() == nil {
+			logging.FromContext(ctx).Error(err, "Failed to collect node metric", "node", nodeInfo.NodeName)
 		}
-		nodeMetricList = append(nodeMetricList, newNodeMetric)
-
+		return
 	}
-
-	return nodeInfoList, nodeMetricList, nil
+	//메트릭의 UUID로 노드의 GPU 목록 갱신
+	Allocator.UpdateNode(nodeInfo.NodeName, newNodeMetric)
+	nodeInfo.Metric = newNodeMetric
 }
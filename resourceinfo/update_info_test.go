@@ -0,0 +1,68 @@
+package resourceinfo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	st "gpu-scheduler/testing"
+)
+
+// fakeMetricsProvider returns the same metric and error for every node.
+type fakeMetricsProvider struct {
+	metric *NodeMetric
+	err    error
+}
+
+func (p *fakeMetricsProvider) NodeMetric(ctx context.Context, nodeName string) (*NodeMetric, error) {
+	return p.metric, p.err
+}
+
+func (p *fakeMetricsProvider) Close() error {
+	return nil
+}
+
+func TestUpdateNodeMetric(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   *fakeMetricsProvider
+		wantMetric bool
+		wantUUIDs  []string //GPUs of the node after the update
+	}{
+		{
+			name:       "metric replaces the GPUs of the node",
+			provider:   &fakeMetricsProvider{metric: &NodeMetric{NodeName: "update-node", UUIDs: []string{"gpu-1"}}},
+			wantMetric: true,
+			wantUUIDs:  []string{"gpu-1"},
+		},
+		{
+			name: "failed query keeps the GPUs and drops the partial metric",
+			provider: &fakeMetricsProvider{
+				metric: &NodeMetric{NodeName: "update-node", UUIDs: []string{}},
+				err:    fmt.Errorf("query timeout"),
+			},
+			wantUUIDs: []string{"gpu-0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Allocator.UpdateNode("update-node", &NodeMetric{NodeName: "update-node", UUIDs: []string{"gpu-0"}})
+			nodeInfo := NewNodeInfo(st.MakeNode().Name("update-node").Obj())
+			nodeInfo.Metric = &NodeMetric{NodeName: "update-node"}
+
+			UpdateNodeMetric(context.TODO(), tt.provider, nodeInfo)
+
+			if got := nodeInfo.Metric != nil; got != tt.wantMetric {
+				t.Errorf("node has metric = %v, want %v", got, tt.wantMetric)
+			}
+			uuids := make([]string, 0)
+			for _, device := range Allocator.Devices("update-node") {
+				uuids = append(uuids, device.UUID)
+			}
+			if !reflect.DeepEqual(uuids, tt.wantUUIDs) {
+				t.Errorf("GPUs = %v, want %v", uuids, tt.wantUUIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// Package testing builds the pods and nodes used by the scheduler tests.
+package testing
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// PodWrapper builds a pod field by field.
+type PodWrapper struct{ corev1.Pod }
+
+// MakePod returns a pod in the default namespace.
+func MakePod() *PodWrapper {
+	p := &PodWrapper{}
+	p.SetNamespace(corev1.NamespaceDefault)
+	return p
+}
+
+// Obj returns the pod.
+func (p *PodWrapper) Obj() *corev1.Pod {
+	return &p.Pod
+}
+
+func (p *PodWrapper) Name(name string) *PodWrapper {
+	p.SetName(name)
+	return p
+}
+
+func (p *PodWrapper) Namespace(namespace string) *PodWrapper {
+	p.SetNamespace(namespace)
+	return p
+}
+
+func (p *PodWrapper) UID(uid string) *PodWrapper {
+	p.SetUID(types.UID(uid))
+	return p
+}
+
+// Node binds the pod to the node.
+func (p *PodWrapper) Node(nodeName string) *PodWrapper {
+	p.Spec.NodeName = nodeName
+	return p
+}
+
+func (p *PodWrapper) Phase(phase corev1.PodPhase) *PodWrapper {
+	p.Status.Phase = phase
+	return p
+}
+
+func (p *PodWrapper) Priority(priority int32) *PodWrapper {
+	p.Spec.Priority = &priority
+	return p
+}
+
+func (p *PodWrapper) Label(key, value string) *PodWrapper {
+	if p.Labels == nil {
+		p.Labels = make(map[string]string)
+	}
+	p.Labels[key] = value
+	return p
+}
+
+func (p *PodWrapper) Annotation(key, value string) *PodWrapper {
+	if p.Annotations == nil {
+		p.Annotations = make(map[string]string)
+	}
+	p.Annotations[key] = value
+	return p
+}
+
+// Req adds a container requesting the resources, e.g. {"cpu": "500m"}.
+func (p *PodWrapper) Req(requests map[corev1.ResourceName]string) *PodWrapper {
+	p.Spec.Containers = append(p.Spec.Containers, corev1.Container{
+		Name:      "container",
+		Resources: corev1.ResourceRequirements{Requests: resourceList(requests)},
+	})
+	return p
+}
+
+// InitReq adds an init container requesting the resources.
+func (p *PodWrapper) InitReq(requests map[corev1.ResourceName]string) *PodWrapper {
+	p.Spec.InitContainers = append(p.Spec.InitContainers, corev1.Container{
+		Name:      "init-container",
+		Resources: corev1.ResourceRequirements{Requests: resourceList(requests)},
+	})
+	return p
+}
+
+func (p *PodWrapper) Overhead(overhead map[corev1.ResourceName]string) *PodWrapper {
+	p.Spec.Overhead = resourceList(overhead)
+	return p
+}
+
+// Toleration adds a toleration of every taint with the key to the pod.
+func (p *PodWrapper) Toleration(key string) *PodWrapper {
+	p.Spec.Tolerations = append(p.Spec.Tolerations, corev1.Toleration{Key: key, Operator: corev1.TolerationOpExists})
+	return p
+}
+
+// PodAffinity adds a required pod affinity term on the pods matching the labels.
+func (p *PodWrapper) PodAffinity(topologyKey string, matchLabels map[string]string) *PodWrapper {
+	if p.Spec.Affinity == nil {
+		p.Spec.Affinity = &corev1.Affinity{}
+	}
+	if p.Spec.Affinity.PodAffinity == nil {
+		p.Spec.Affinity.PodAffinity = &corev1.PodAffinity{}
+	}
+	affinity := p.Spec.Affinity.PodAffinity
+	affinity.RequiredDuringSchedulingIgnoredDuringExecution = append(affinity.RequiredDuringSchedulingIgnoredDuringExecution,
+		podAffinityTerm(topologyKey, matchLabels))
+	return p
+}
+
+// PodAntiAffinity adds a required pod anti-affinity term on the pods matching the labels.
+func (p *PodWrapper) PodAntiAffinity(topologyKey string, matchLabels map[string]string) *PodWrapper {
+	if p.Spec.Affinity == nil {
+		p.Spec.Affinity = &corev1.Affinity{}
+	}
+	if p.Spec.Affinity.PodAntiAffinity == nil {
+		p.Spec.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+	antiAffinity := p.Spec.Affinity.PodAntiAffinity
+	antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution,
+		podAffinityTerm(topologyKey, matchLabels))
+	return p
+}
+
+// PreferredPodAffinity adds a preferred pod affinity term on the pods matching the labels.
+func (p *PodWrapper) PreferredPodAffinity(weight int32, topologyKey string, matchLabels map[string]string) *PodWrapper {
+	if p.Spec.Affinity == nil {
+		p.Spec.Affinity = &corev1.Affinity{}
+	}
+	if p.Spec.Affinity.PodAffinity == nil {
+		p.Spec.Affinity.PodAffinity = &corev1.PodAffinity{}
+	}
+	affinity := p.Spec.Affinity.PodAffinity
+	affinity.PreferredDuringSchedulingIgnoredDuringExecution = append(affinity.PreferredDuringSchedulingIgnoredDuringExecution,
+		corev1.WeightedPodAffinityTerm{Weight: weight, PodAffinityTerm: podAffinityTerm(topologyKey, matchLabels)})
+	return p
+}
+
+// PreferredPodAntiAffinity adds a preferred pod anti-affinity term on the pods matching the labels.
+func (p *PodWrapper) PreferredPodAntiAffinity(weight int32, topologyKey string, matchLabels map[string]string) *PodWrapper {
+	if p.Spec.Affinity == nil {
+		p.Spec.Affinity = &corev1.Affinity{}
+	}
+	if p.Spec.Affinity.PodAntiAffinity == nil {
+		p.Spec.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+	antiAffinity := p.Spec.Affinity.PodAntiAffinity
+	antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+		corev1.WeightedPodAffinityTerm{Weight: weight, PodAffinityTerm: podAffinityTerm(topologyKey, matchLabels)})
+	return p
+}
+
+func podAffinityTerm(topologyKey string, matchLabels map[string]string) corev1.PodAffinityTerm {
+	return corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{MatchLabels: matchLabels},
+		TopologyKey:   topologyKey,
+	}
+}
+
+// NodeWrapper builds a node field by field.
+type NodeWrapper struct{ corev1.Node }
+
+func MakeNode() *NodeWrapper {
+	return &NodeWrapper{}
+}
+
+// Obj returns the node.
+func (n *NodeWrapper) Obj() *corev1.Node {
+	return &n.Node
+}
+
+// Name sets the name and the kubernetes.io/hostname label of the node.
+func (n *NodeWrapper) Name(name string) *NodeWrapper {
+	n.SetName(name)
+	return n.Label(corev1.LabelHostname, name)
+}
+
+func (n *NodeWrapper) Label(key, value string) *NodeWrapper {
+	if n.Labels == nil {
+		n.Labels = make(map[string]string)
+	}
+	n.Labels[key] = value
+	return n
+}
+
+// Taint adds a taint to the node.
+func (n *NodeWrapper) Taint(key, value string, effect corev1.TaintEffect) *NodeWrapper {
+	n.Spec.Taints = append(n.Spec.Taints, corev1.Taint{Key: key, Value: value, Effect: effect})
+	return n
+}
+
+func resourceList(quantities map[corev1.ResourceName]string) corev1.ResourceList {
+	rl := make(corev1.ResourceList, len(quantities))
+	for name, quantity := range quantities {
+		rl[name] = resource.MustParse(quantity)
+	}
+	return rl
+}
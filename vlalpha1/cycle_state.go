@@ -0,0 +1,85 @@
+package v1alpha1
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	resource "gpu-scheduler/resourceinfo"
+)
+
+// ErrNotFound is returned by CycleState.Read when the key was never written.
+var ErrNotFound = errors.New("not found")
+
+// StateKey is the key of plugin data in the CycleState.
+type StateKey string
+
+// StateData is any data a plugin keeps for the rest of the attempt.
+type StateData interface{}
+
+// CycleState holds the state of one scheduling attempt: the node snapshot
+// it filters, the number of feasible nodes found and plugin scratch data.
+// A new CycleState is created for every attempt and dropped when its binding
+// finishes, so nothing carries over to the next attempt. It is safe for the
+// plugins running in parallel across nodes.
+type CycleState struct {
+	mu       sync.RWMutex
+	storage  map[StateKey]StateData
+	snapshot []*resource.NodeInfo
+
+	feasibleNodes int32
+}
+
+func NewCycleState() *CycleState {
+	return &CycleState{
+		storage: make(map[StateKey]StateData),
+	}
+}
+
+// Read returns the data written under key, or ErrNotFound.
+func (c *CycleState) Read(key StateKey) (StateData, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if data, ok := c.storage[key]; ok {
+		return data, nil
+	}
+	return nil, ErrNotFound
+}
+
+// Write stores data under key, replacing what was written before.
+func (c *CycleState) Write(key StateKey, data StateData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.storage[key] = data
+}
+
+func (c *CycleState) Delete(key StateKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.storage, key)
+}
+
+// SetSnapshot records the nodes of the attempt, taken once before filtering.
+func (c *CycleState) SetSnapshot(nodes []*resource.NodeInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.snapshot = nodes
+}
+
+// Snapshot returns every node of the attempt, feasible or not.
+func (c *CycleState) Snapshot() []*resource.NodeInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.snapshot
+}
+
+// AddFeasibleNode counts one more node that passed filtering and returns
+// the new count.
+func (c *CycleState) AddFeasibleNode() int {
+	return int(atomic.AddInt32(&c.feasibleNodes, 1))
+}
+
+// FeasibleNodes returns the number of nodes that passed filtering.
+func (c *CycleState) FeasibleNodes() int {
+	return int(atomic.LoadInt32(&c.feasibleNodes))
+}
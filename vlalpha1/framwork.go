@@ -1,26 +1,400 @@
 package v1alpha1
 
-// import (
-// 	corev1 "k8s.io/api/core/v1"
-// )
-
-// const (
-// 	filter  = "Filter"
-// 	score   = "Score"
-// 	bind    = "Bind"
-// 	reserve = "Reserve"
-// )
-
-// type Framework struct {
-// 	filterPlugins []FilterPlugin
-// 	scorePlugins  []ScorePlugin
-// 	bindPlugins   []BindPlugin
-// }
-
-// var _ gpuschedulerFramework = &Framework{}
-
-// // RunFilterPlugins runs the set of configured Filter plugins for pod on
-// // the given node. If any of these plugins doesn't return "Success", the
-// // given node is not suitable for running pod.
-// // Meanwhile, the failure message and status are set for the given node.
-// func (f *Framework) RunFilterPlugins(pod *corev1.Pod)
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gpu-scheduler/config"
+	"gpu-scheduler/logging"
+	"gpu-scheduler/parallelize"
+	resource "gpu-scheduler/resourceinfo"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	MaxNodeScore = 100
+
+	// maxPermitTimeout is the longest time a Permit plugin can make a pod wait.
+	maxPermitTimeout = 15 * time.Minute
+)
+
+// PluginFactory builds a plugin.
+type PluginFactory func() (Plugin, error)
+
+// Registry maps plugin names to their factories.
+type Registry map[string]PluginFactory
+
+// Merge adds the plugins of other to the registry.
+func (r Registry) Merge(other Registry) error {
+	for name, factory := range other {
+		if _, ok := r[name]; ok {
+			return fmt.Errorf("plugin %q is already registered", name)
+		}
+		r[name] = factory
+	}
+	return nil
+}
+
+// PluginConfig enables a plugin. Weight is only used by score plugins.
+type PluginConfig struct {
+	Name   string
+	Weight float64
+}
+
+// Framework runs the configured plugins at each extension point.
+type Framework struct {
+	preFilterPlugins []PreFilterPlugin
+	filterPlugins    []FilterPlugin
+	preScorePlugins  []PreScorePlugin
+	scorePlugins     []ScorePlugin
+	reservePlugins   []ReservePlugin
+	permitPlugins    []PermitPlugin
+	preBindPlugins   []PreBindPlugin
+	bindPlugins      []BindPlugin
+	postBindPlugins  []PostBindPlugin
+
+	scorePluginWeight map[string]float64
+	waitingPods       *waitingPodsMap
+}
+
+// NewFramework builds each enabled plugin once and adds it to every
+// extension point it implements, in the order the plugins are given.
+func NewFramework(registry Registry, plugins []PluginConfig) (*Framework, error) {
+	f := &Framework{
+		scorePluginWeight: make(map[string]float64),
+		waitingPods:       newWaitingPodsMap(),
+	}
+
+	for _, pluginConfig := range plugins {
+		factory, ok := registry[pluginConfig.Name]
+		if !ok {
+			return nil, fmt.Errorf("plugin %q does not exist", pluginConfig.Name)
+		}
+		plugin, err := factory()
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize plugin %q: %v", pluginConfig.Name, err)
+		}
+
+		if p, ok := plugin.(PreFilterPlugin); ok {
+			f.preFilterPlugins = append(f.preFilterPlugins, p)
+		}
+		if p, ok := plugin.(FilterPlugin); ok {
+			f.filterPlugins = append(f.filterPlugins, p)
+		}
+		if p, ok := plugin.(ScorePlugin); ok && pluginConfig.Weight > 0 {
+			f.scorePlugins = append(f.scorePlugins, p)
+			f.scorePluginWeight[p.Name()] = pluginConfig.Weight
+			//점수를 매기지 않는 플러그인은 PreScore도 실행하지 않음
+			if pp, ok := plugin.(PreScorePlugin); ok {
+				f.preScorePlugins = append(f.preScorePlugins, pp)
+			}
+		}
+		if p, ok := plugin.(ReservePlugin); ok {
+			f.reservePlugins = append(f.reservePlugins, p)
+		}
+		if p, ok := plugin.(PermitPlugin); ok {
+			f.permitPlugins = append(f.permitPlugins, p)
+		}
+		if p, ok := plugin.(PreBindPlugin); ok {
+			f.preBindPlugins = append(f.preBindPlugins, p)
+		}
+		if p, ok := plugin.(BindPlugin); ok {
+			f.bindPlugins = append(f.bindPlugins, p)
+		}
+		if p, ok := plugin.(PostBindPlugin); ok {
+			f.postBindPlugins = append(f.postBindPlugins, p)
+		}
+	}
+
+	if len(f.bindPlugins) == 0 {
+		return nil, fmt.Errorf("at least one bind plugin is needed")
+	}
+	return f, nil
+}
+
+// RunPreFilterPlugins runs the set of configured PreFilter plugins. It
+// returns the first status that is not a success.
+func (f *Framework) RunPreFilterPlugins(ctx context.Context, state *CycleState, pod *corev1.Pod) *Status {
+	for _, pl := range f.preFilterPlugins {
+		status := pl.PreFilter(ctx, state, pod)
+		if !status.IsSuccess() {
+			return NewStatus(status.Code(), fmt.Sprintf("prefilter plugin %q: %s", pl.Name(), status.Message()))
+		}
+	}
+	return nil
+}
+
+// RunFilterPlugins runs the set of configured Filter plugins for pod on
+// the given node. If any of these plugins doesn't return "Success", the
+// given node is not suitable for running pod. The reasons of every failed
+// plugin are collected in the returned status.
+func (f *Framework) RunFilterPlugins(ctx context.Context, state *CycleState, pod *corev1.Pod, nodeInfo *resource.NodeInfo) *Status {
+	reasons := make([]string, 0)
+	for _, pl := range f.filterPlugins {
+		status := pl.Filter(ctx, state, pod, nodeInfo)
+		switch status.Code() {
+		case Success:
+		case Unschedulable:
+			reasons = append(reasons, status.Reasons()...)
+		default:
+			return NewStatus(Error, fmt.Sprintf("filter plugin %q: %s", pl.Name(), status.Message()))
+		}
+	}
+	if len(reasons) > 0 {
+		return NewStatus(Unschedulable, reasons...)
+	}
+	return nil
+}
+
+// RunPreScorePlugins runs the PreScore plugins in order. It returns the
+// first status that is not a success.
+func (f *Framework) RunPreScorePlugins(ctx context.Context, state *CycleState, pod *corev1.Pod, nodes []*resource.NodeInfo) *Status {
+	for _, pl := range f.preScorePlugins {
+		status := pl.PreScore(ctx, state, pod, nodes)
+		if !status.IsSuccess() {
+			return NewStatus(Error, fmt.Sprintf("prescore plugin %q: %s", pl.Name(), status.Message()))
+		}
+	}
+	return nil
+}
+
+// RunScorePlugins scores the nodes with every Score plugin, normalizes the
+// scores of the plugins with ScoreExtensions and returns the weighted sum
+// per node.
+func (f *Framework) RunScorePlugins(ctx context.Context, state *CycleState, pod *corev1.Pod, nodes []*resource.NodeInfo) (NodeScoreList, *Status) {
+	pluginToNodeScores := make(map[string]NodeScoreList, len(f.scorePlugins))
+	for _, pl := range f.scorePlugins {
+		pluginToNodeScores[pl.Name()] = make(NodeScoreList, len(nodes))
+	}
+
+	//노드별로 병렬 스코어링, 점수는 노드 순서대로 저장
+	scoreCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errCh := parallelize.NewErrorChannel()
+	parallelize.Until(scoreCtx, config.Parallelism, len(nodes), func(i int) {
+		for _, pl := range f.scorePlugins {
+			score, status := pl.Score(scoreCtx, state, pod, nodes[i])
+			if !status.IsSuccess() {
+				errCh.SendErrorWithCancel(fmt.Errorf("score plugin %q: %s", pl.Name(), status.Message()), cancel)
+				return
+			}
+			pluginToNodeScores[pl.Name()][i] = NodeScore{Name: nodes[i].NodeName, Score: score}
+		}
+	})
+	if err := errCh.ReceiveError(); err != nil {
+		return nil, NewStatus(Error, err.Error())
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, AsStatus(err)
+	}
+
+	result := make(NodeScoreList, len(nodes))
+	for i, nodeInfo := range nodes {
+		result[i] = NodeScore{Name: nodeInfo.NodeName}
+	}
+	for _, pl := range f.scorePlugins {
+		scores := pluginToNodeScores[pl.Name()]
+		if extensions := pl.ScoreExtensions(); extensions != nil {
+			status := extensions.NormalizeScore(ctx, state, pod, scores)
+			if !status.IsSuccess() {
+				return nil, NewStatus(Error, fmt.Sprintf("normalize score plugin %q: %s", pl.Name(), status.Message()))
+			}
+		}
+
+		weight := f.scorePluginWeight[pl.Name()]
+		v := logging.FromContext(ctx).V(5)
+		for i := range scores {
+			if scores[i].Score < 0 || scores[i].Score > MaxNodeScore {
+				return nil, NewStatus(Error, fmt.Sprintf("score plugin %q returns an invalid score %v for node %s", pl.Name(), scores[i].Score, scores[i].Name))
+			}
+			v.Info("Plugin scored node", "plugin", pl.Name(), "node", scores[i].Name, "score", scores[i].Score, "weight", weight)
+			result[i].Score += weight * scores[i].Score
+		}
+	}
+	return result, nil
+}
+
+// RunReservePlugins runs the Reserve plugins in order. If one fails, the
+// plugins already run are unreserved.
+func (f *Framework) RunReservePlugins(ctx context.Context, state *CycleState, pod *corev1.Pod, nodeName string) *Status {
+	for i, pl := range f.reservePlugins {
+		status := pl.Reserve(ctx, state, pod, nodeName)
+		if !status.IsSuccess() {
+			for j := i - 1; j >= 0; j-- {
+				f.reservePlugins[j].Unreserve(ctx, state, pod, nodeName)
+			}
+			return NewStatus(status.Code(), fmt.Sprintf("reserve plugin %q: %s", pl.Name(), status.Message()))
+		}
+	}
+	return nil
+}
+
+// RunUnreservePlugins runs the Unreserve of every Reserve plugin in reverse order.
+func (f *Framework) RunUnreservePlugins(ctx context.Context, state *CycleState, pod *corev1.Pod, nodeName string) {
+	for i := len(f.reservePlugins) - 1; i >= 0; i-- {
+		f.reservePlugins[i].Unreserve(ctx, state, pod, nodeName)
+	}
+}
+
+// RunPermitPlugins runs the Permit plugins. If any plugin returns Wait, the
+// pod waits until every waiting plugin allows it, one rejects it, or the
+// shortest timeout expires.
+func (f *Framework) RunPermitPlugins(ctx context.Context, state *CycleState, pod *corev1.Pod, nodeName string) *Status {
+	pluginsWaitTime := make(map[string]time.Duration)
+	for _, pl := range f.permitPlugins {
+		status, timeout := pl.Permit(ctx, state, pod, nodeName)
+		switch status.Code() {
+		case Success:
+		case Wait:
+			if timeout > maxPermitTimeout {
+				timeout = maxPermitTimeout
+			}
+			pluginsWaitTime[pl.Name()] = timeout
+		default:
+			return NewStatus(status.Code(), fmt.Sprintf("permit plugin %q: %s", pl.Name(), status.Message()))
+		}
+	}
+	if len(pluginsWaitTime) == 0 {
+		return nil
+	}
+
+	waitingPod := newWaitingPod(pod, pluginsWaitTime)
+	f.waitingPods.add(waitingPod)
+	defer f.waitingPods.remove(pod.UID)
+
+	select {
+	case status := <-waitingPod.s:
+		return status
+	case <-ctx.Done():
+		return NewStatus(Error, ctx.Err().Error())
+	}
+}
+
+// GetWaitingPod returns the pod waiting in the Permit phase, if any.
+func (f *Framework) GetWaitingPod(uid types.UID) *WaitingPod {
+	return f.waitingPods.get(uid)
+}
+
+// RunPreBindPlugins runs the PreBind plugins and stops at the first failure.
+func (f *Framework) RunPreBindPlugins(ctx context.Context, state *CycleState, pod *corev1.Pod, nodeName string) *Status {
+	for _, pl := range f.preBindPlugins {
+		status := pl.PreBind(ctx, state, pod, nodeName)
+		if !status.IsSuccess() {
+			return NewStatus(status.Code(), fmt.Sprintf("prebind plugin %q: %s", pl.Name(), status.Message()))
+		}
+	}
+	return nil
+}
+
+// RunBindPlugins runs the Bind plugins until one of them does not Skip.
+func (f *Framework) RunBindPlugins(ctx context.Context, state *CycleState, pod *corev1.Pod, nodeName string) *Status {
+	for _, pl := range f.bindPlugins {
+		status := pl.Bind(ctx, state, pod, nodeName)
+		if status.Code() == Skip {
+			continue
+		}
+		if !status.IsSuccess() {
+			return NewStatus(status.Code(), fmt.Sprintf("bind plugin %q: %s", pl.Name(), status.Message()))
+		}
+		return nil
+	}
+	return NewStatus(Error, "no bind plugin bound the pod")
+}
+
+// RunPostBindPlugins informs the PostBind plugins that the pod is bound.
+func (f *Framework) RunPostBindPlugins(ctx context.Context, state *CycleState, pod *corev1.Pod, nodeName string) {
+	for _, pl := range f.postBindPlugins {
+		pl.PostBind(ctx, state, pod, nodeName)
+	}
+}
+
+// WaitingPod is a pod held in the Permit phase.
+type WaitingPod struct {
+	pod            *corev1.Pod
+	mu             sync.Mutex
+	pendingPlugins map[string]*time.Timer
+	s              chan *Status
+}
+
+func newWaitingPod(pod *corev1.Pod, pluginsWaitTime map[string]time.Duration) *WaitingPod {
+	wp := &WaitingPod{
+		pod:            pod,
+		pendingPlugins: make(map[string]*time.Timer),
+		s:              make(chan *Status, 1),
+	}
+	for name, waitTime := range pluginsWaitTime {
+		pluginName, timeout := name, waitTime
+		wp.pendingPlugins[pluginName] = time.AfterFunc(timeout, func() {
+			wp.Reject(fmt.Sprintf("rejected due to timeout after waiting %v at plugin %s", timeout, pluginName))
+		})
+	}
+	return wp
+}
+
+func (w *WaitingPod) GetPod() *corev1.Pod {
+	return w.pod
+}
+
+// Allow marks the pod allowed by the plugin. The pod is bound once every
+// waiting plugin allowed it.
+func (w *WaitingPod) Allow(pluginName string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if timer, ok := w.pendingPlugins[pluginName]; ok {
+		timer.Stop()
+		delete(w.pendingPlugins, pluginName)
+	}
+	if len(w.pendingPlugins) != 0 {
+		return
+	}
+	select {
+	case w.s <- nil:
+	default:
+	}
+}
+
+// Reject rejects the pod.
+func (w *WaitingPod) Reject(msg string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, timer := range w.pendingPlugins {
+		timer.Stop()
+	}
+	select {
+	case w.s <- NewStatus(Unschedulable, msg):
+	default:
+	}
+}
+
+type waitingPodsMap struct {
+	mu   sync.RWMutex
+	pods map[types.UID]*WaitingPod
+}
+
+func newWaitingPodsMap() *waitingPodsMap {
+	return &waitingPodsMap{
+		pods: make(map[types.UID]*WaitingPod),
+	}
+}
+
+func (m *waitingPodsMap) add(wp *WaitingPod) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pods[wp.pod.UID] = wp
+}
+
+func (m *waitingPodsMap) remove(uid types.UID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pods, uid)
+}
+
+func (m *waitingPodsMap) get(uid types.UID) *WaitingPod {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.pods[uid]
+}
@@ -1,3 +1,171 @@
 package v1alpha1
 
-type gpuscheduler struct{}
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	resource "gpu-scheduler/resourceinfo"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Code is the result of running a plugin.
+type Code int
+
+const (
+	// Success means that plugin ran correctly and found pod schedulable.
+	Success Code = iota
+	// Error is used for internal plugin errors, unexpected input, etc.
+	Error
+	// Unschedulable is used when a plugin finds a pod unschedulable.
+	Unschedulable
+	// Wait is used when a Permit plugin finds a pod scheduling should wait.
+	Wait
+	// Skip is used when a Bind plugin chooses to skip binding.
+	Skip
+)
+
+var codes = []string{"Success", "Error", "Unschedulable", "Wait", "Skip"}
+
+func (c Code) String() string {
+	return codes[c]
+}
+
+// Status indicates the result of running a plugin. A nil Status is a Success.
+type Status struct {
+	code    Code
+	reasons []string
+}
+
+func NewStatus(code Code, reasons ...string) *Status {
+	return &Status{
+		code:    code,
+		reasons: reasons,
+	}
+}
+
+// AsStatus wraps an error in a Status with code Error.
+func AsStatus(err error) *Status {
+	if err == nil {
+		return nil
+	}
+	return NewStatus(Error, err.Error())
+}
+
+func (s *Status) Code() Code {
+	if s == nil {
+		return Success
+	}
+	return s.code
+}
+
+func (s *Status) Reasons() []string {
+	if s == nil {
+		return nil
+	}
+	return s.reasons
+}
+
+func (s *Status) Message() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(s.reasons, ", ")
+}
+
+func (s *Status) IsSuccess() bool {
+	return s.Code() == Success
+}
+
+func (s *Status) IsUnschedulable() bool {
+	return s.Code() == Unschedulable
+}
+
+// AsError returns nil if the status is a success; otherwise returns an error.
+func (s *Status) AsError() error {
+	if s.IsSuccess() {
+		return nil
+	}
+	return errors.New(s.Message())
+}
+
+// NodeScore is the score of a node for the pod.
+type NodeScore struct {
+	Name  string
+	Score float64
+}
+
+type NodeScoreList []NodeScore
+
+// Plugin is the parent type for all the scheduling framework plugins.
+type Plugin interface {
+	Name() string
+}
+
+// PreFilterPlugin is called once per scheduling cycle before the nodes are filtered.
+type PreFilterPlugin interface {
+	Plugin
+	PreFilter(ctx context.Context, state *CycleState, pod *corev1.Pod) *Status
+}
+
+// FilterPlugin checks whether the pod can run on the node.
+type FilterPlugin interface {
+	Plugin
+	Filter(ctx context.Context, state *CycleState, pod *corev1.Pod, nodeInfo *resource.NodeInfo) *Status
+}
+
+// PreScorePlugin is called once per scheduling cycle with the nodes that
+// passed filtering, before they are scored.
+type PreScorePlugin interface {
+	Plugin
+	PreScore(ctx context.Context, state *CycleState, pod *corev1.Pod, nodes []*resource.NodeInfo) *Status
+}
+
+// ScorePlugin ranks the nodes that passed the filtering phase.
+type ScorePlugin interface {
+	Plugin
+	Score(ctx context.Context, state *CycleState, pod *corev1.Pod, nodeInfo *resource.NodeInfo) (float64, *Status)
+	// ScoreExtensions returns a ScoreExtensions interface if it implements one, or nil if does not.
+	ScoreExtensions() ScoreExtensions
+}
+
+// ScoreExtensions is an interface for Score extended functionality.
+type ScoreExtensions interface {
+	// NormalizeScore changes the scores of all nodes to the range [0, MaxNodeScore].
+	NormalizeScore(ctx context.Context, state *CycleState, pod *corev1.Pod, scores NodeScoreList) *Status
+}
+
+// ReservePlugin reserves resources of the selected node for the pod before
+// it is bound. Unreserve is called if any later phase fails.
+type ReservePlugin interface {
+	Plugin
+	Reserve(ctx context.Context, state *CycleState, pod *corev1.Pod, nodeName string) *Status
+	Unreserve(ctx context.Context, state *CycleState, pod *corev1.Pod, nodeName string)
+}
+
+// PermitPlugin approves, rejects or delays the binding of the pod.
+type PermitPlugin interface {
+	Plugin
+	Permit(ctx context.Context, state *CycleState, pod *corev1.Pod, nodeName string) (*Status, time.Duration)
+}
+
+// PreBindPlugin is called before the pod is bound.
+type PreBindPlugin interface {
+	Plugin
+	PreBind(ctx context.Context, state *CycleState, pod *corev1.Pod, nodeName string) *Status
+}
+
+// BindPlugin binds the pod to the node. A plugin that does not handle the
+// pod returns Skip so that the next bind plugin is tried.
+type BindPlugin interface {
+	Plugin
+	Bind(ctx context.Context, state *CycleState, pod *corev1.Pod, nodeName string) *Status
+}
+
+// PostBindPlugin is informed after the pod has been bound.
+type PostBindPlugin interface {
+	Plugin
+	PostBind(ctx context.Context, state *CycleState, pod *corev1.Pod, nodeName string)
+}